@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// tokenPath is the endpoint that exchanges a user's credentials for an API
+// token, and userPath the one describing the user a token belongs to.
+const (
+	tokenPath = "/anubis/rest/v1/oauth/token"
+	userPath  = "/anubis/rest/v1/user"
+)
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+// credentialsTokenSource logs in with the configured username and password,
+// both for the initial token and whenever the current one expires.
+type credentialsTokenSource struct {
+	config *ProviderConfig
+}
+
+// RefreshToken is called with the token lock held, so it sends its request
+// directly instead of through makeRequest, which reads the token.
+func (s *credentialsTokenSource) RefreshToken(ctx context.Context) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "password")
+	form.Set("username", s.config.Username)
+	form.Set("password", s.config.Password)
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.WriteTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.config.BaseURL+tokenPath, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.config.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Error requesting token: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("Error requesting token: %s", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", apiError(resp.StatusCode, resp.Header, body)
+	}
+
+	var token tokenResponse
+	if err := decodeJSON(body, &token); err != nil {
+		return "", fmt.Errorf("Error parsing response: %s", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("No token in response")
+	}
+	return token.AccessToken, nil
+}
+
+// checkCredentials makes a cheap authenticated request, so that a bad or
+// expired token is reported when the provider is configured rather than by
+// the first resource that calls the API.
+func checkCredentials(ctx context.Context, config *ProviderConfig) error {
+	_, err := makeRequest(ctx, config, "GET", config.BaseURL+userPath, nil)
+	return err
+}
+
+// credentialsError turns a failed checkCredentials into a diagnostic naming
+// the CDO region the credentials were checked against.
+func credentialsError(config *ProviderConfig, err error) diag.Diagnostic {
+	region := config.Region
+	if region == "" {
+		region = config.BaseURL
+		if u, parseErr := url.Parse(config.BaseURL); parseErr == nil {
+			region = u.Host
+		}
+	}
+
+	if !errors.Is(err, errUnauthorized) {
+		return diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("Could not check the CDO credentials for %s", region),
+			Detail:   fmt.Sprintf("Error checking the provider credentials: %s", err),
+		}
+	}
+
+	attribute := "token"
+	if config.Username != "" {
+		attribute = "password"
+	}
+	return diag.Diagnostic{
+		Severity:      diag.Error,
+		Summary:       fmt.Sprintf("Invalid CDO credentials for %s", region),
+		Detail:        "CDO rejected the configured credentials. Check that they haven't expired or been revoked, and that they belong to the tenant of this base_url.",
+		AttributePath: cty.GetAttrPath(attribute),
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// cdfmcGlobalDomainUID is the UID of the Global domain, the only domain of a
+// cloud-delivered FMC.
+const cdfmcGlobalDomainUID = "e276abec-e0f2-11e3-8169-6d9ed49b625f"
+
+const (
+	ruleActionAllow   = "ALLOW"
+	ruleActionBlock   = "BLOCK"
+	ruleActionTrust   = "TRUST"
+	ruleActionMonitor = "MONITOR"
+)
+
+const accessPolicyPath = "/policy/accesspolicies"
+
+// AccessPolicy is a cdFMC access control policy.
+type AccessPolicy struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+type accessPolicyPage struct {
+	Items []AccessPolicy `json:"items"`
+}
+
+// findAccessPolicyByName returns the access policy with exactly the given
+// name.
+func findAccessPolicyByName(ctx context.Context, config *ProviderConfig, name string) (*AccessPolicy, error) {
+	params := url.Values{}
+	params.Set("name", name)
+	params.Set("expanded", "true")
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		cdfmcURL(config, fmt.Sprintf("%s?%s", accessPolicyPath, params.Encode())),
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Error listing access policies: %s", err)
+	}
+
+	var page accessPolicyPage
+	if err := decodeJSON(resp, &page); err != nil {
+		return nil, fmt.Errorf("Error parsing response: %s", err)
+	}
+
+	// The name filter may match partially, only keep exact matches.
+	var matches []AccessPolicy
+	for _, policy := range page.Items {
+		if policy.Name == name {
+			matches = append(matches, policy)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no access policy named %q found", name)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("%d access policies named %q found", len(matches), name)
+	}
+}
+
+// DeviceRecord holds the settings cdFMC keeps for a device it manages.
+type DeviceRecord struct {
+	ID                     string   `json:"id"`
+	Type                   string   `json:"type"`
+	Name                   string   `json:"name"`
+	NatID                  string   `json:"natID,omitempty"`
+	LicenseCaps            []string `json:"license_caps"`
+	ProhibitPacketTransfer bool     `json:"prohibitPacketTransfer"`
+}
+
+func getDeviceRecord(ctx context.Context, config *ProviderConfig, id string) (*DeviceRecord, error) {
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		cdfmcURL(config, fmt.Sprintf("/devices/devicerecords/%s", id)),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var record DeviceRecord
+	if err := decodeJSON(resp, &record); err != nil {
+		return nil, fmt.Errorf("Error parsing response: %s", err)
+	}
+	return &record, nil
+}
+
+func updateDeviceRecord(ctx context.Context, config *ProviderConfig, record *DeviceRecord) error {
+	_, err := makeRequest(
+		ctx,
+		config,
+		"PUT",
+		cdfmcURL(config, fmt.Sprintf("/devices/devicerecords/%s", record.ID)),
+		record,
+	)
+	if err != nil {
+		return fmt.Errorf("Error updating device record: %s", err)
+	}
+	return nil
+}
+
+// AccessRule is a rule of a cdFMC access control policy.
+type AccessRule struct {
+	ID                  string           `json:"id,omitempty"`
+	Name                string           `json:"name"`
+	Type                string           `json:"type"`
+	Action              string           `json:"action"`
+	Enabled             bool             `json:"enabled"`
+	SourceNetworks      *networkLiterals `json:"sourceNetworks,omitempty"`
+	DestinationNetworks *networkLiterals `json:"destinationNetworks,omitempty"`
+}
+
+type accessRulePage struct {
+	Items []AccessRule `json:"items"`
+}
+
+type networkLiterals struct {
+	Literals []networkLiteral `json:"literals"`
+}
+
+type networkLiteral struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// createCDFMCObject creates an object at path, a cdFMC object collection such
+// as "/object/hosts", and decodes the created object into out.
+func createCDFMCObject(ctx context.Context, config *ProviderConfig, path string, payload, out interface{}) error {
+	resp, err := makeRequest(ctx, config, "POST", cdfmcURL(config, path), payload)
+	if err != nil {
+		return err
+	}
+	if err := decodeJSON(resp, out); err != nil {
+		return fmt.Errorf("Error parsing response: %s", err)
+	}
+	return nil
+}
+
+func getCDFMCObject(ctx context.Context, config *ProviderConfig, path, id string, out interface{}) error {
+	resp, err := makeRequest(ctx, config, "GET", cdfmcURL(config, fmt.Sprintf("%s/%s", path, id)), nil)
+	if err != nil {
+		return err
+	}
+	if err := decodeJSON(resp, out); err != nil {
+		return fmt.Errorf("Error parsing response: %s", err)
+	}
+	return nil
+}
+
+func updateCDFMCObject(ctx context.Context, config *ProviderConfig, path, id string, payload interface{}) error {
+	_, err := makeRequest(ctx, config, "PUT", cdfmcURL(config, fmt.Sprintf("%s/%s", path, id)), payload)
+	return err
+}
+
+// deleteCDFMCObject deletes an object, treating one that is already gone as
+// deleted.
+func deleteCDFMCObject(ctx context.Context, config *ProviderConfig, path, id string) error {
+	_, err := makeRequest(ctx, config, "DELETE", cdfmcURL(config, fmt.Sprintf("%s/%s", path, id)), nil)
+	if errors.Is(err, errNotFound) {
+		return nil
+	}
+	return err
+}
+
+// cdfmcURL returns the URL of a cdFMC configuration API path in the Global
+// domain, proxied through CDO.
+func cdfmcURL(config *ProviderConfig, path string) string {
+	return config.apiURL(fmt.Sprintf("/cdfmc/api/fmc_config/v1/domain/%s%s", cdfmcGlobalDomainUID, path))
+}
+
+// createAccessRules adds rules to an access control policy in a single bulk
+// request and returns the IDs of the created rules.
+func createAccessRules(ctx context.Context, config *ProviderConfig, policyUID string, rules []AccessRule) ([]string, error) {
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		cdfmcURL(config, fmt.Sprintf("%s/%s/accessrules?bulk=true", accessPolicyPath, policyUID)),
+		rules,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating access rules: %s", err)
+	}
+
+	var created accessRulePage
+	if err := decodeJSON(resp, &created); err != nil {
+		return nil, fmt.Errorf("Error parsing response: %s", err)
+	}
+	ids := make([]string, 0, len(created.Items))
+	for _, rule := range created.Items {
+		ids = append(ids, rule.ID)
+	}
+	return ids, nil
+}
+
+// deleteAccessRules deletes the rules with the given IDs from an access
+// control policy. Rules that are already gone are skipped.
+func deleteAccessRules(ctx context.Context, config *ProviderConfig, policyUID string, ids []string) error {
+	for _, id := range ids {
+		if err := deleteCDFMCObject(ctx, config, fmt.Sprintf("%s/%s/accessrules", accessPolicyPath, policyUID), id); err != nil {
+			return fmt.Errorf("Error deleting access rule %s: %s", id, err)
+		}
+	}
+	return nil
+}
+
+// networkLiteralsFor turns a list of addresses into cdFMC network literals,
+// or nil when the list is empty so the rule matches any network.
+func networkLiteralsFor(values []interface{}) *networkLiterals {
+	if len(values) == 0 {
+		return nil
+	}
+
+	literals := &networkLiterals{}
+	for _, v := range values {
+		literals.Literals = append(literals.Literals, networkLiteral{Type: "Network", Value: v.(string)})
+	}
+	return literals
+}
@@ -0,0 +1,649 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNotFound matches the error makeRequest returns when the API answers 404,
+// so callers can tell a missing entity apart from other failures with
+// errors.Is.
+var errNotFound = errors.New("API request failed with status 404")
+
+// errUnauthorized matches the error makeRequest returns when the API rejects
+// the token.
+var errUnauthorized = errors.New("API request failed with status 401: the CDO API token was rejected, it may have expired. Set a valid token, or the credentials of a CDO API user, in the provider configuration")
+
+// errOffline is returned instead of sending a request when the provider is
+// configured with offline = true.
+var errOffline = errors.New("The provider is configured with offline = true and can't reach CDO. Unset offline and run this from a network with access to CDO")
+
+const (
+	defaultReadTimeout   = 30 * time.Second
+	defaultWriteTimeout  = 2 * time.Minute
+	defaultDeleteTimeout = time.Minute
+)
+
+// maxErrorBodyBytes caps how much of an error response without a recognized
+// error envelope is quoted in error messages.
+const maxErrorBodyBytes = 512
+
+const (
+	defaultMaxRetries = 3
+	defaultRetryWait  = 2 * time.Second
+	maxRetryDelay     = 30 * time.Second
+	maxRedirects      = 10
+
+	maxIdleConnsPerHost = 10
+)
+
+// workspaceHeader carries the Terraform workspace so CDO audit logs show
+// which workspace initiated an operation.
+const workspaceHeader = "X-Terraform-Workspace"
+
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+var acceptableResponseCodes = map[int]struct{}{
+	http.StatusOK:                   {},
+	http.StatusCreated:              {},
+	http.StatusAccepted:             {},
+	http.StatusNonAuthoritativeInfo: {},
+	http.StatusNoContent:            {},
+	http.StatusResetContent:         {},
+	http.StatusPartialContent:       {},
+}
+
+// apiResponse is the successful response to an API request.
+type apiResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// makeRequest sends an API request, retrying transient failures, and returns
+// the body of the successful response.
+func makeRequest(ctx context.Context, config *ProviderConfig, method, url string, payload interface{}) ([]byte, error) {
+	resp, err := makeAPIRequest(ctx, config, method, url, payload)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// makeAPIRequest is makeRequest for callers that also need the status code
+// of the response, e.g. to tell "204 No Content" apart from a body.
+func makeAPIRequest(ctx context.Context, config *ProviderConfig, method, url string, payload interface{}) (*apiResponse, error) {
+	if config.Offline {
+		return nil, errOffline
+	}
+
+	var payloadBytes []byte
+	if payload != nil {
+		var err error
+		payloadBytes, err = json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A POST or PATCH that may have reached CDO isn't sent again, as that
+	// could e.g. onboard a device twice.
+	idempotent := isIdempotent(method)
+
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		token := config.Token()
+		statusCode, header, body, err := doRequest(ctx, config, method, url, payloadBytes, attempt)
+		var connErr *connectionError
+		if errors.As(err, &connErr) && (idempotent || connErr.notSent()) && ctx.Err() == nil && attempt <= config.MaxRetries {
+			logAPIEvent(ctx, config, "WARN", "Retrying API request after connection error", map[string]interface{}{
+				"method":  method,
+				"url":     url,
+				"error":   err.Error(),
+				"attempt": attempt,
+			})
+			if err := sleepContext(ctx, retryBackoff(attempt, config.RetryWait, nil)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		retryableBody := idempotent && hasRetryableErrorBody(config, body)
+		// A 429 means the request was turned away without being processed,
+		// so it is safe to retry whatever the method.
+		retryableStatus := isRetryable(config, statusCode) && (idempotent || statusCode == http.StatusTooManyRequests)
+		if (retryableStatus || retryableBody) && attempt <= config.MaxRetries {
+			logAPIEvent(ctx, config, "WARN", "Retrying API request", map[string]interface{}{
+				"method":  method,
+				"url":     url,
+				"status":  statusCode,
+				"attempt": attempt,
+			})
+			if err := sleepContext(ctx, retryBackoff(attempt, config.RetryWait, header)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if statusCode == http.StatusUnauthorized {
+			// A token that expired mid-apply is refreshed once; a second
+			// rejection means the new token isn't accepted either.
+			if !refreshed && config.refreshToken(ctx, token) == nil {
+				refreshed = true
+				attempt--
+				continue
+			}
+		}
+		if _, ok := acceptableResponseCodes[statusCode]; !ok {
+			return nil, apiError(statusCode, header, body)
+		}
+		if retryableBody {
+			return nil, fmt.Errorf("API request still reported a transient error after %d attempts: %s", attempt, errorDetail(body))
+		}
+		return &apiResponse{StatusCode: statusCode, Body: body}, nil
+	}
+}
+
+// retryBackoff returns how long to wait before retrying after the given
+// attempt. A Retry-After header sent by the API takes precedence; otherwise
+// the delay starts at wait and doubles with every attempt, with jitter so
+// that parallel resources don't retry in lockstep. Either way it never
+// exceeds maxRetryDelay.
+func retryBackoff(attempt int, wait time.Duration, header http.Header) time.Duration {
+	if delay, ok := retryAfter(header); ok {
+		if delay > maxRetryDelay {
+			return maxRetryDelay
+		}
+		return delay
+	}
+
+	delay := maxRetryDelay
+	if attempt < 16 {
+		if d := wait << (attempt - 1); d < maxRetryDelay {
+			delay = d
+		}
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an
+// HTTP date.
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// connectionError marks failures to get any response from the API, which are
+// worth retrying unlike errors building the request.
+type connectionError struct {
+	err error
+}
+
+func (e *connectionError) Error() string { return e.err.Error() }
+
+func (e *connectionError) Unwrap() error { return e.err }
+
+// notSent reports whether the request failed before reaching the API, while
+// connecting, so that it is safe to send again whatever its method.
+func (e *connectionError) notSent() bool {
+	var opErr *net.OpError
+	return errors.As(e.err, &opErr) && opErr.Op == "dial"
+}
+
+// isIdempotent reports whether sending a request with method more than once
+// has the same effect as sending it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// cdoError is the envelope CDO wraps error responses in.
+type cdoError struct {
+	ErrorCode    string `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+	Message      string `json:"message"`
+}
+
+// apiError describes a failed API request, including what the response body
+// says went wrong, classified by its status code so that callers and
+// diagnostics can tell rejected credentials, missing entities, rate limiting
+// and server failures apart.
+func apiError(statusCode int, header http.Header, body []byte) error {
+	base := apiStatusError{StatusCode: statusCode, Code: errorCode(body), Detail: errorDetail(body)}
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return &authError{base}
+	case statusCode == http.StatusNotFound:
+		return &notFoundError{base}
+	case statusCode == http.StatusTooManyRequests:
+		delay, _ := retryAfter(header)
+		return &rateLimitError{apiStatusError: base, RetryAfter: delay}
+	case statusCode >= http.StatusInternalServerError:
+		return &serverError{base}
+	}
+	return &base
+}
+
+// apiStatusError is returned for API requests that failed with a status
+// code that has no dedicated error type, and is wrapped by those that do.
+type apiStatusError struct {
+	StatusCode int
+	// Code is the errorCode of the CDO error envelope, if the response had
+	// one.
+	Code   string
+	Detail string
+}
+
+func (e *apiStatusError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Detail)
+	}
+	return fmt.Sprintf("API request failed with status %d", e.StatusCode)
+}
+
+// authError is returned when the API rejects the credentials (401), or when
+// the user's role doesn't allow the request (403). A 401 matches
+// errUnauthorized.
+type authError struct{ apiStatusError }
+
+func (e *authError) Error() string {
+	if e.StatusCode == http.StatusUnauthorized {
+		return errUnauthorized.Error()
+	}
+	return e.apiStatusError.Error()
+}
+
+func (e *authError) Unwrap() error { return &e.apiStatusError }
+
+func (e *authError) Is(target error) bool {
+	return target == errUnauthorized && e.StatusCode == http.StatusUnauthorized
+}
+
+// notFoundError is returned when the API answers 404. It matches errNotFound.
+type notFoundError struct{ apiStatusError }
+
+func (e *notFoundError) Unwrap() error { return &e.apiStatusError }
+
+func (e *notFoundError) Is(target error) bool { return target == errNotFound }
+
+// rateLimitError is returned when the API still throttled the request after
+// the retries. RetryAfter is how long the API asked to wait, if it said.
+type rateLimitError struct {
+	apiStatusError
+	RetryAfter time.Duration
+}
+
+func (e *rateLimitError) Unwrap() error { return &e.apiStatusError }
+
+// serverError is returned when the API still failed with a 5xx status after
+// the retries.
+type serverError struct{ apiStatusError }
+
+func (e *serverError) Unwrap() error { return &e.apiStatusError }
+
+// isTemporary reports whether err is a failure that is expected to go away by
+// itself: a lost connection, rate limiting or a server error.
+func isTemporary(err error) bool {
+	var connErr *connectionError
+	var rateErr *rateLimitError
+	var srvErr *serverError
+	return errors.As(err, &connErr) || errors.As(err, &rateErr) || errors.As(err, &srvErr)
+}
+
+// hasStatus reports whether err is an API error with the given status code.
+func hasStatus(err error, statusCode int) bool {
+	var statusErr *apiStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == statusCode
+}
+
+// errorCode returns the errorCode of a CDO error envelope, or an empty string
+// when body isn't one.
+func errorCode(body []byte) string {
+	var envelope cdoError
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return ""
+	}
+	return envelope.ErrorCode
+}
+
+// errorDetail extracts the message of a CDO error envelope, falling back to
+// the redacted and truncated body when it isn't one.
+func errorDetail(body []byte) string {
+	var envelope cdoError
+	if err := json.Unmarshal(body, &envelope); err == nil {
+		message := envelope.ErrorMessage
+		if message == "" {
+			message = envelope.Message
+		}
+		if message != "" && envelope.ErrorCode != "" {
+			return fmt.Sprintf("%s (%s)", message, envelope.ErrorCode)
+		}
+		if message != "" {
+			return message
+		}
+	}
+
+	var decoded interface{}
+	if err := decodeJSON(body, &decoded); err == nil {
+		if redacted, err := json.Marshal(redactFields(decoded)); err == nil {
+			body = redacted
+		}
+	}
+
+	detail := strings.TrimSpace(string(body))
+	if len(detail) > maxErrorBodyBytes {
+		detail = detail[:maxErrorBodyBytes] + "..."
+	}
+	return detail
+}
+
+// doRequest performs a single attempt of an API call and returns the status
+// code and body of the response.
+func doRequest(ctx context.Context, config *ProviderConfig, method, url string, payload []byte, attempt int) (int, http.Header, []byte, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+		if config.CompressRequests {
+			compressed, err := gzipBody(payload)
+			if err != nil {
+				return 0, nil, nil, err
+			}
+			body = bytes.NewReader(compressed)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout(config, method, url))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", config.Token()))
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+		if config.CompressRequests {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+	}
+	if config.Workspace != "" {
+		req.Header.Set(workspaceHeader, config.Workspace)
+	}
+	if config.AcceptLanguage != "" {
+		req.Header.Set("Accept-Language", config.AcceptLanguage)
+	}
+
+	if config.RequestInterceptor != nil {
+		if err := config.RequestInterceptor(req); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	if err := config.limiter.Wait(ctx); err != nil {
+		return 0, nil, nil, err
+	}
+
+	logAPIEvent(ctx, config, "TRACE", "Sending API request", map[string]interface{}{
+		"method":  method,
+		"url":     url,
+		"attempt": attempt,
+		"headers": loggableHeaders(req.Header),
+	})
+
+	start := time.Now()
+	resp, err := config.httpClient().Do(req)
+	if err != nil {
+		logAPIEvent(ctx, config, "DEBUG", "API request failed", map[string]interface{}{
+			"method":     method,
+			"url":        url,
+			"attempt":    attempt,
+			"elapsed_ms": time.Since(start).Milliseconds(),
+			"error":      err.Error(),
+		})
+		return 0, nil, nil, &connectionError{err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, &connectionError{err}
+	}
+
+	fields := map[string]interface{}{
+		"method":     method,
+		"url":        url,
+		"status":     resp.StatusCode,
+		"attempt":    attempt,
+		"elapsed_ms": time.Since(start).Milliseconds(),
+	}
+	if b := loggableBody(config, payload); b != "" {
+		fields["request_body"] = b
+	}
+	if b := loggableBody(config, respBody); b != "" {
+		fields["response_body"] = b
+	}
+	logAPIEvent(ctx, config, "DEBUG", "API request completed", fields)
+
+	return resp.StatusCode, resp.Header, respBody, nil
+}
+
+// requestTimeout picks the HTTP timeout for a request from the kind of
+// operation it performs: reads (including transaction polls), deletes, or
+// writes such as onboarding, updates and deployments.
+func requestTimeout(config *ProviderConfig, method, url string) time.Duration {
+	switch {
+	case method == http.MethodGet:
+		return config.ReadTimeout
+	case method == http.MethodDelete || strings.HasSuffix(url, "/delete"):
+		return config.DeleteTimeout
+	default:
+		return config.WriteTimeout
+	}
+}
+
+func gzipBody(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// isRetryable reports whether a response with the given status code should
+// be retried, according to the provider's retry_status_codes.
+func isRetryable(config *ProviderConfig, statusCode int) bool {
+	for _, code := range config.RetryStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// newHTTPClient returns the client shared by every API request. Requests
+// carry their own, method specific timeout; the client's timeout only bounds
+// the longest of them.
+func newHTTPClient(config *ProviderConfig) *http.Client {
+	timeout := config.ReadTimeout
+	for _, t := range []time.Duration{config.WriteTimeout, config.DeleteTimeout} {
+		if t > timeout {
+			timeout = t
+		}
+	}
+
+	return &http.Client{
+		Transport: newTransport(config),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return checkRedirect(config.BaseURL, req, via)
+		},
+		Timeout: timeout,
+	}
+}
+
+func newTransport(config *ProviderConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Polling loops and parallel resources all talk to the same CDO host.
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	setProxy(transport, config)
+	if config.PinnedCertSHA256 != "" {
+		// The pin identifies the exact certificate the server must present,
+		// so it replaces CA and hostname verification instead of adding to it.
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true,
+			VerifyConnection: func(cs tls.ConnectionState) error {
+				return verifyPinnedCert(cs, config.PinnedCertSHA256)
+			},
+		}
+	} else if config.CACertificate != "" || config.InsecureSkipVerify {
+		// A CA certificate that fails to load has already been reported by
+		// Validate.
+		rootCAs, _ := loadCACertificate(config.CACertificate)
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:            rootCAs,
+			InsecureSkipVerify: config.InsecureSkipVerify,
+		}
+	}
+	return transport
+}
+
+// setProxy routes transport through the provider's proxy_url, if set. Without
+// one the transport keeps using the proxy from the environment.
+func setProxy(transport *http.Transport, config *ProviderConfig) {
+	if config.ProxyURL != "" {
+		if proxy, err := url.Parse(config.ProxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(proxy)
+		}
+	}
+}
+
+// verifyPinnedCert checks that the server's leaf certificate has the pinned
+// SHA-256 fingerprint.
+func verifyPinnedCert(cs tls.ConnectionState, pin string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("server presented no certificate")
+	}
+
+	fingerprint := sha256.Sum256(cs.PeerCertificates[0].Raw)
+	if hex.EncodeToString(fingerprint[:]) != normalizeFingerprint(pin) {
+		return fmt.Errorf("server certificate fingerprint %x does not match pinned_cert_sha256", fingerprint)
+	}
+	return nil
+}
+
+// normalizeFingerprint accepts fingerprints written with or without colons
+// and in either case.
+func normalizeFingerprint(pin string) string {
+	return strings.ToLower(strings.ReplaceAll(pin, ":", ""))
+}
+
+// checkRedirect follows redirects between the endpoints of the CDO instance
+// at baseURL, re-attaching the Authorization header that net/http drops when
+// the host changes. Redirects to any other host are followed without
+// credentials.
+func checkRedirect(baseURL string, req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	original := via[0]
+	if isTrustedRedirect(baseURL, original.URL, req.URL) {
+		req.Header.Set("Authorization", original.Header.Get("Authorization"))
+	} else {
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
+
+// isTrustedRedirect reports whether credentials for from may be sent to to:
+// either the redirect stays on the same origin, or it moves to the origin of
+// baseURL or of one of the CDO regions. Origins are compared exactly, so other
+// hosts of the same domain aren't trusted.
+func isTrustedRedirect(baseURL string, from, to *url.URL) bool {
+	if sameOrigin(from, to) {
+		return true
+	}
+	trusted := []string{baseURL}
+	for _, regionURL := range regionBaseURLs {
+		trusted = append(trusted, regionURL)
+	}
+	for _, origin := range trusted {
+		if u, err := url.Parse(origin); err == nil && sameOrigin(u, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameOrigin reports whether a and b have the same scheme, host and port.
+func sameOrigin(a, b *url.URL) bool {
+	return a.Scheme == b.Scheme && a.Host == b.Host
+}
+
+// hasRetryableErrorBody reports whether a response body contains one of the
+// provider's retryable_error_substrings, for endpoints that signal transient
+// failures in a 2xx body rather than through the status code.
+func hasRetryableErrorBody(config *ProviderConfig, body []byte) bool {
+	for _, substring := range config.RetryableErrorSubstrings {
+		if bytes.Contains(body, []byte(substring)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sleepContext waits for d, returning early with the context's error if ctx
+// is cancelled first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestConfig returns a provider configuration that sends its requests to
+// a test server running handler, with retries that don't slow tests down.
+func newTestConfig(t *testing.T, handler http.Handler) *ProviderConfig {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config := &ProviderConfig{
+		BaseURL:          server.URL,
+		APIVersion:       defaultAPIVersion,
+		LogFormat:        logFormatText,
+		ReadTimeout:      defaultReadTimeout,
+		WriteTimeout:     defaultWriteTimeout,
+		DeleteTimeout:    defaultDeleteTimeout,
+		PollInterval:     time.Millisecond,
+		MaxRetries:       2,
+		RetryWait:        time.Millisecond,
+		RetryStatusCodes: defaultRetryStatusCodes,
+		HTTPClient:       server.Client(),
+		TokenSource:      staticTokenSource{},
+	}
+	config.SetToken("token")
+	return config
+}
+
+func TestMakeRequestRetriesServerErrors(t *testing.T) {
+	var calls int32
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"name":"ftd-1"}`))
+	}))
+
+	body, err := makeRequest(context.Background(), config, "GET", config.apiURL("/inventory/devices/1"), nil)
+	if err != nil {
+		t.Fatalf("makeRequest() error = %s", err)
+	}
+	if string(body) != `{"name":"ftd-1"}` {
+		t.Errorf("makeRequest() = %s, want the body of the successful response", body)
+	}
+	if calls != 3 {
+		t.Errorf("got %d requests, want 3", calls)
+	}
+}
+
+func TestMakeRequestGivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	_, err := makeRequest(context.Background(), config, "GET", config.apiURL("/inventory/devices/1"), nil)
+	var serverErr *serverError
+	if !errors.As(err, &serverErr) || serverErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("makeRequest() error = %v, want a 502 serverError", err)
+	}
+	if want := int32(config.MaxRetries + 1); calls != want {
+		t.Errorf("got %d requests, want %d", calls, want)
+	}
+}
+
+func TestMakeRequestDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.NotFound(w, r)
+	}))
+
+	_, err := makeRequest(context.Background(), config, "GET", config.apiURL("/inventory/devices/1"), nil)
+	if !errors.Is(err, errNotFound) {
+		t.Fatalf("makeRequest() error = %v, want errNotFound", err)
+	}
+	if calls != 1 {
+		t.Errorf("got %d requests, want 1", calls)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempt  int
+		wait     time.Duration
+		header   http.Header
+		min, max time.Duration
+	}{
+		{
+			name:    "first attempt",
+			attempt: 1,
+			wait:    2 * time.Second,
+			min:     time.Second,
+			max:     2 * time.Second,
+		},
+		{
+			name:    "doubles with every attempt",
+			attempt: 3,
+			wait:    2 * time.Second,
+			min:     4 * time.Second,
+			max:     8 * time.Second,
+		},
+		{
+			name:    "capped",
+			attempt: 20,
+			wait:    2 * time.Second,
+			min:     maxRetryDelay / 2,
+			max:     maxRetryDelay,
+		},
+		{
+			name:    "Retry-After",
+			attempt: 1,
+			wait:    2 * time.Second,
+			header:  http.Header{"Retry-After": []string{"7"}},
+			min:     7 * time.Second,
+			max:     7 * time.Second,
+		},
+		{
+			name:    "Retry-After capped",
+			attempt: 1,
+			wait:    2 * time.Second,
+			header:  http.Header{"Retry-After": []string{"3600"}},
+			min:     maxRetryDelay,
+			max:     maxRetryDelay,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if got := retryBackoff(tt.attempt, tt.wait, tt.header); got < tt.min || got > tt.max {
+					t.Fatalf("retryBackoff() = %s, want between %s and %s", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+// countingTokenSource hands out token, counting how often it is asked.
+type countingTokenSource struct {
+	token string
+	calls int32
+}
+
+func (s *countingTokenSource) RefreshToken(context.Context) (string, error) {
+	atomic.AddInt32(&s.calls, 1)
+	return s.token, nil
+}
+
+func TestMakeRequestRefreshesRejectedToken(t *testing.T) {
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	source := &countingTokenSource{token: "fresh"}
+	config.TokenSource = source
+
+	if _, err := makeRequest(context.Background(), config, "GET", config.apiURL("/inventory/devices"), nil); err != nil {
+		t.Fatalf("makeRequest() error = %s", err)
+	}
+	if got := config.Token(); got != "fresh" {
+		t.Errorf("Token() = %q, want the refreshed token", got)
+	}
+	if source.calls != 1 {
+		t.Errorf("token refreshed %d times, want 1", source.calls)
+	}
+}
+
+func TestMakeRequestRefreshesTokenOnce(t *testing.T) {
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	source := &countingTokenSource{token: "also-rejected"}
+	config.TokenSource = source
+
+	_, err := makeRequest(context.Background(), config, "GET", config.apiURL("/inventory/devices"), nil)
+	if !errors.Is(err, errUnauthorized) {
+		t.Fatalf("makeRequest() error = %v, want errUnauthorized", err)
+	}
+	if source.calls != 1 {
+		t.Errorf("token refreshed %d times, want 1", source.calls)
+	}
+}
+
+func TestMakeRequestWithStaticToken(t *testing.T) {
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	_, err := makeRequest(context.Background(), config, "GET", config.apiURL("/inventory/devices"), nil)
+	if !errors.Is(err, errUnauthorized) {
+		t.Fatalf("makeRequest() error = %v, want errUnauthorized", err)
+	}
+}
+
+func TestWaitForPollsUntilDone(t *testing.T) {
+	statuses := []string{"PENDING", "IN_PROGRESS", "IN_PROGRESS", "DONE"}
+	var calls int32
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[atomic.AddInt32(&calls, 1)-1]
+		w.Write([]byte(`{"transactionUid":"tx-1","cdoTransactionStatus":"` + status + `"}`))
+	}))
+
+	opts := cdoTransactionWait
+	opts.Interval = time.Millisecond
+	opts.MaxAttempts = 10
+	if err := waitFor(context.Background(), config, config.apiURL("/transactions/tx-1"), opts); err != nil {
+		t.Fatalf("waitFor() error = %s", err)
+	}
+	if want := int32(len(statuses)); calls != want {
+		t.Errorf("got %d polls, want %d", calls, want)
+	}
+}
+
+func TestIsTrustedRedirect(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		from, to string
+		want     bool
+	}{
+		{
+			name:    "same origin",
+			baseURL: "https://www.defenseorchestrator.com",
+			from:    "https://www.defenseorchestrator.com/api/rest/v1/a",
+			to:      "https://www.defenseorchestrator.com/api/rest/v1/b",
+			want:    true,
+		},
+		{
+			name:    "other region",
+			baseURL: "https://www.defenseorchestrator.com",
+			from:    "https://www.defenseorchestrator.com/api/rest/v1/a",
+			to:      "https://www.defenseorchestrator.eu/api/rest/v1/a",
+			want:    true,
+		},
+		{
+			name:    "back to base_url",
+			baseURL: "https://cdo.example.co.uk",
+			from:    "https://edge.example.co.uk/api/rest/v1/a",
+			to:      "https://cdo.example.co.uk/api/rest/v1/a",
+			want:    true,
+		},
+		{
+			name:    "other host of the same domain",
+			baseURL: "https://www.defenseorchestrator.com",
+			from:    "https://www.defenseorchestrator.com/api/rest/v1/a",
+			to:      "https://edge.defenseorchestrator.com/api/rest/v1/a",
+		},
+		{
+			name:    "other host under a public suffix",
+			baseURL: "https://cdo.example.co.uk",
+			from:    "https://cdo.example.co.uk/api/rest/v1/a",
+			to:      "https://attacker.co.uk/a",
+		},
+		{
+			name:    "other app on a shared hosting domain",
+			baseURL: "https://cdo-proxy.herokuapp.com",
+			from:    "https://cdo-proxy.herokuapp.com/api/rest/v1/a",
+			to:      "https://attacker.herokuapp.com/a",
+		},
+		{
+			name:    "other domain",
+			baseURL: "https://www.defenseorchestrator.com",
+			from:    "https://www.defenseorchestrator.com/api/rest/v1/a",
+			to:      "https://www.example.com/a",
+		},
+		{
+			name:    "other port",
+			baseURL: "https://www.defenseorchestrator.com",
+			from:    "https://www.defenseorchestrator.com/api/rest/v1/a",
+			to:      "https://www.defenseorchestrator.com:8443/api/rest/v1/a",
+		},
+		{
+			name:    "downgrade to http",
+			baseURL: "https://www.defenseorchestrator.com",
+			from:    "https://www.defenseorchestrator.com/api/rest/v1/a",
+			to:      "http://www.defenseorchestrator.com/api/rest/v1/a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, _ := url.Parse(tt.from)
+			to, _ := url.Parse(tt.to)
+			if got := isTrustedRedirect(tt.baseURL, from, to); got != tt.want {
+				t.Errorf("isTrustedRedirect() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeRequestDropsTokenOnCrossOriginRedirect(t *testing.T) {
+	var authorization atomic.Value
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(foreign.Close)
+
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, foreign.URL+"/collect", http.StatusFound)
+	}))
+	// Use the provider's own client, which decides what to do on redirects.
+	config.HTTPClient = nil
+
+	if _, err := makeRequest(context.Background(), config, "GET", config.apiURL("/inventory/devices"), nil); err != nil {
+		t.Fatalf("makeRequest() error = %s", err)
+	}
+	if got := authorization.Load(); got != "" {
+		t.Errorf("Authorization = %q sent to another origin, want none", got)
+	}
+}
+
+func TestMakeRequestRetriesByMethod(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		status    int
+		wantCalls int32
+	}{
+		{name: "POST server error", method: "POST", status: http.StatusInternalServerError, wantCalls: 1},
+		{name: "PATCH server error", method: "PATCH", status: http.StatusBadGateway, wantCalls: 1},
+		{name: "POST rate limited", method: "POST", status: http.StatusTooManyRequests, wantCalls: 3},
+		{name: "PUT server error", method: "PUT", status: http.StatusInternalServerError, wantCalls: 3},
+		{name: "DELETE server error", method: "DELETE", status: http.StatusServiceUnavailable, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.status)
+			}))
+
+			if _, err := makeRequest(context.Background(), config, tt.method, config.apiURL("/inventory/devices"), map[string]string{"name": "ftd-1"}); err == nil {
+				t.Fatal("makeRequest() succeeded, want an error")
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("got %d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMakeRequestRetriesPOSTThatWasNotSent(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	config := newTestConfig(t, http.NotFoundHandler())
+	// Nothing listens on the address of a closed server, so connecting fails.
+	config.BaseURL = server.URL
+	server.Close()
+
+	var attempts int32
+	config.RequestInterceptor = func(*http.Request) error {
+		atomic.AddInt32(&attempts, 1)
+		return nil
+	}
+
+	_, err := makeRequest(context.Background(), config, "POST", config.apiURL("/inventory/devices"), map[string]string{"name": "ftd-1"})
+	var connErr *connectionError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("makeRequest() error = %v, want a connection error", err)
+	}
+	if want := int32(config.MaxRetries + 1); attempts != want {
+		t.Errorf("got %d attempts, want %d", attempts, want)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// dataSourceAccessPolicy resolves a cdFMC access policy by name, so configs
+// don't have to hard-code policy UUIDs that differ between tenants.
+func dataSourceAccessPolicy() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceAccessPolicyRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"uuid": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceAccessPolicyRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	policy, err := findAccessPolicyByName(ctx, config, d.Get("name").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(policy.ID)
+	d.Set("uuid", policy.ID)
+	d.Set("description", policy.Description)
+	d.Set("type", policy.Type)
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// CDFMC is the cloud-delivered FMC of the tenant.
+type CDFMC struct {
+	Uid             flexString `json:"uid"`
+	Hostname        string     `json:"hostname"`
+	DomainUid       string     `json:"domainUuid"`
+	SoftwareVersion string     `json:"softwareVersion"`
+}
+
+// dataSourceCDFMC returns the details of the tenant's cloud-delivered FMC, e.g.
+// the domain UID some onboarding and cdFMC APIs take.
+func dataSourceCDFMC() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceCDFMCRead,
+
+		Schema: map[string]*schema.Schema{
+			"uid": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"hostname": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"domain_uid": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"software_version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceCDFMCRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL("/cdfmc"),
+		nil,
+	)
+	if err != nil {
+		return diag.Errorf("Error reading cdFMC: %s", err)
+	}
+
+	var cdfmc CDFMC
+	if err := decodeJSON(resp, &cdfmc); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	d.SetId(string(cdfmc.Uid))
+	d.Set("uid", string(cdfmc.Uid))
+	d.Set("hostname", cdfmc.Hostname)
+	d.Set("domain_uid", cdfmc.DomainUid)
+	d.Set("software_version", cdfmc.SoftwareVersion)
+	return nil
+}
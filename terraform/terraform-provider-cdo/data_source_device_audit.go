@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+type AuditEntry struct {
+	Action    string     `json:"action"`
+	User      string     `json:"user"`
+	Timestamp flexString `json:"timestamp"`
+}
+
+type auditPage struct {
+	Items []AuditEntry `json:"items"`
+}
+
+func dataSourceDeviceAudit() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDeviceAuditRead,
+
+		Schema: map[string]*schema.Schema{
+			"device_uid": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"limit": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      10,
+				ValidateFunc: validation.IntBetween(1, 200),
+				Description:  "Number of most recent audit entries to return.",
+			},
+			"entries": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"action": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"user": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"timestamp": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceDeviceAuditRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+	deviceUID := d.Get("device_uid").(string)
+
+	params := url.Values{}
+	params.Set("q", inventoryQuery("entityUid", deviceUID))
+	params.Set("limit", fmt.Sprint(d.Get("limit").(int)))
+	params.Set("sort", "timestamp:desc")
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/changelogs?%s", params.Encode())),
+		nil,
+	)
+	if err != nil {
+		return diag.Errorf("Error reading device audit log: %s", err)
+	}
+
+	var page auditPage
+	if err := decodeJSON(resp, &page); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	entries := make([]interface{}, 0, len(page.Items))
+	for _, entry := range page.Items {
+		entries = append(entries, map[string]interface{}{
+			"action":    entry.Action,
+			"user":      entry.User,
+			"timestamp": string(entry.Timestamp),
+		})
+	}
+
+	d.SetId(deviceUID)
+	d.Set("entries", entries)
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// dataSourceDeviceGroup looks up a device group by name, e.g. to run bulk
+// actions against a group managed outside of Terraform.
+func dataSourceDeviceGroup() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDeviceGroupRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"uid": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"device_uids": {
+				Type:     schema.TypeSet,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceDeviceGroupRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	group, err := findDeviceGroupByName(ctx, config, d.Get("name").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(string(group.Uid))
+	d.Set("uid", string(group.Uid))
+	d.Set("device_uids", group.DeviceUids)
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type DeviceHealth struct {
+	Status             string  `json:"healthStatus"`
+	CPUUsagePercent    float64 `json:"cpuUsagePercentage"`
+	MemoryUsagePercent float64 `json:"memoryUsagePercentage"`
+	DiskUsagePercent   float64 `json:"diskUsagePercentage"`
+}
+
+func dataSourceDeviceHealth() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDeviceHealthRead,
+
+		Schema: map[string]*schema.Schema{
+			"device_uid": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"cpu_usage_percent": {
+				Type:     schema.TypeFloat,
+				Computed: true,
+			},
+			"memory_usage_percent": {
+				Type:     schema.TypeFloat,
+				Computed: true,
+			},
+			"disk_usage_percent": {
+				Type:     schema.TypeFloat,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceDeviceHealthRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+	deviceUID := d.Get("device_uid").(string)
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/inventory/devices/%s/health", deviceUID)),
+		nil,
+	)
+	if err != nil {
+		return diag.Errorf("Error reading device health: %s", err)
+	}
+
+	var health DeviceHealth
+	if err := decodeJSON(resp, &health); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	d.SetId(deviceUID)
+	d.Set("status", health.Status)
+	d.Set("cpu_usage_percent", health.CPUUsagePercent)
+	d.Set("memory_usage_percent", health.MemoryUsagePercent)
+	d.Set("disk_usage_percent", health.DiskUsagePercent)
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// dataSourceDevices lists the inventory entries matching the given filters,
+// e.g. to build for_each maps over all devices of one type.
+func dataSourceDevices() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDevicesRead,
+
+		Schema: map[string]*schema.Schema{
+			"device_type": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return devices of this CDO device type, e.g. FTDC or ASA.",
+			},
+			"connectivity_state": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return devices in this connectivity state, e.g. ONLINE.",
+			},
+			"name_prefix": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return devices whose name starts with this prefix.",
+			},
+			"labels": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Only return devices that have all of these labels.",
+			},
+			"devices": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"uid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"serial_number": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"device_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"connectivity_state": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"labels": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceDevicesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	var clauses []string
+	if v, ok := d.GetOk("device_type"); ok {
+		clauses = append(clauses, inventoryQuery("deviceType", v.(string)))
+	}
+	if v, ok := d.GetOk("connectivity_state"); ok {
+		clauses = append(clauses, inventoryQuery("connectivityState", v.(string)))
+	}
+	query := strings.Join(clauses, " AND ")
+
+	devices, err := listDevices(ctx, config, query)
+	if err != nil {
+		return diag.Errorf("Error listing devices: %s", err)
+	}
+
+	// The inventory query only matches whole values, so prefixes and labels
+	// are filtered here.
+	namePrefix := d.Get("name_prefix").(string)
+	var labels []string
+	for _, v := range d.Get("labels").(*schema.Set).List() {
+		labels = append(labels, v.(string))
+	}
+
+	entries := make([]interface{}, 0, len(devices))
+	for _, device := range devices {
+		if !strings.HasPrefix(device.Name, namePrefix) || !hasAllLabels(device.Labels, labels) {
+			continue
+		}
+		entries = append(entries, map[string]interface{}{
+			"uid":                string(device.Uid),
+			"name":               device.Name,
+			"serial_number":      device.SerialNumber,
+			"device_type":        device.DeviceType,
+			"connectivity_state": device.ConnectivityState,
+			"labels":             device.Labels.UngroupedLabels,
+		})
+	}
+
+	d.SetId(fmt.Sprintf("devices:%s:%s:%s", query, namePrefix, strings.Join(labels, ",")))
+	d.Set("devices", entries)
+	return nil
+}
+
+func hasAllLabels(labels Labels, want []string) bool {
+	for _, label := range want {
+		if !containsString(labels.UngroupedLabels, label) {
+			return false
+		}
+	}
+	return true
+}
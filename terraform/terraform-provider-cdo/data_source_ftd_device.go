@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// dataSourceFTDDevice looks up an FTD that is already onboarded, by UID,
+// serial number or name.
+func dataSourceFTDDevice() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceFTDDeviceRead,
+
+		Schema: map[string]*schema.Schema{
+			"uid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"uid", "serial_number", "name"},
+			},
+			"serial_number": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"access_policy_uuid": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"connectivity_state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"software_version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceFTDDeviceRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	uid := d.Get("uid").(string)
+	var err error
+	switch {
+	case uid != "":
+	case d.Get("serial_number").(string) != "":
+		uid, err = findDeviceUIDBySerial(ctx, config, d.Get("serial_number").(string))
+	default:
+		uid, err = findDeviceUIDByName(ctx, config, d.Get("name").(string))
+	}
+	if err != nil {
+		return diag.Errorf("Error looking up FTD device: %s", err)
+	}
+
+	device, err := getFTDDevice(ctx, config, uid)
+	if err != nil {
+		return diag.Errorf("Error reading FTD device %s: %s", uid, err)
+	}
+
+	d.SetId(uid)
+	d.Set("uid", uid)
+	d.Set("serial_number", device.SerialNumber)
+	d.Set("name", device.Name)
+	d.Set("access_policy_uuid", device.AccessPolicyUid)
+	d.Set("connectivity_state", device.ConnectivityState)
+	d.Set("software_version", device.SoftwareVersion)
+	return nil
+}
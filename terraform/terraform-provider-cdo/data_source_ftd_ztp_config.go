@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// dataSourceFTDZTPConfig renders the request cdo_ftd_device sends to onboard a
+// cloud-delivered FMC managed device through zero-touch provisioning, without
+// calling CDO, so that it can be reviewed at plan time or fed to other
+// tooling. The admin password and smart license token are left out.
+func dataSourceFTDZTPConfig() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceFTDZTPConfigRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateDeviceName,
+			},
+			"serial_number": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateSerialNumber,
+			},
+			"access_policy_uuid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IsUUID,
+				Description:  "Access policy the device is onboarded with. Defaults to the provider's default_access_policy_uuid.",
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"labels": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"licenses": licensesSchema(ftdLicenses, ftdDeviceType.DefaultLicenses),
+			"dns_servers": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.IsIPAddress,
+				},
+			},
+			"performance_tier": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(ftdPerformanceTiers, false),
+				Description:  fmt.Sprintf("Performance tier of a virtual FTD, one of %s.", strings.Join(ftdPerformanceTiers, ", ")),
+			},
+			"license_reservation": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(ftdLicenseReservations, false),
+			},
+			"endpoint": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Path of the CDO API the payload is posted to.",
+			},
+			"payload_json": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Onboarding request body as JSON.",
+			},
+		},
+	}
+}
+
+func dataSourceFTDZTPConfigRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	accessPolicyUUID := d.Get("access_policy_uuid").(string)
+	if accessPolicyUUID == "" {
+		accessPolicyUUID = config.DefaultAccessPolicyUUID
+	}
+	if accessPolicyUUID == "" {
+		return diag.Errorf("access_policy_uuid must be set on the data source or as the provider's default_access_policy_uuid")
+	}
+
+	licenses := deviceLicenses(d, ftdDeviceType)
+
+	payload := ftdOnboardingPayload(d, accessPolicyUUID, licenses)
+	payload["serialNumber"] = d.Get("serial_number").(string)
+	if v, ok := d.GetOk("license_reservation"); ok {
+		payload["licenseReservation"] = v.(string)
+	}
+
+	b, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return diag.Errorf("Error encoding payload: %s", err)
+	}
+
+	d.SetId(d.Get("serial_number").(string))
+	d.Set("access_policy_uuid", accessPolicyUUID)
+	d.Set("licenses", licenses)
+	d.Set("endpoint", ftdDeviceType.OnboardPath)
+	d.Set("payload_json", string(b))
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// dataSourceInventoryRaw returns the assembled inventory as a JSON string, for
+// ad-hoc reporting with jsondecode() without modelling every field.
+func dataSourceInventoryRaw() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceInventoryRawRead,
+
+		Schema: map[string]*schema.Schema{
+			"query": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Optional inventory query used to filter the devices returned.",
+			},
+			"json": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "JSON array of every inventory entry, across all pages.",
+			},
+		},
+	}
+}
+
+func dataSourceInventoryRawRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+	query := d.Get("query").(string)
+
+	items, err := listInventory(ctx, config, query)
+	if err != nil {
+		return diag.Errorf("Error listing inventory: %s", err)
+	}
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+
+	inventory, err := json.Marshal(items)
+	if err != nil {
+		return diag.Errorf("Error encoding inventory: %s", err)
+	}
+
+	d.SetId(fmt.Sprintf("inventory:%s", query))
+	d.Set("json", string(inventory))
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// dataSourceSDC looks up a Secure Device Connector of the tenant, by name or,
+// without one, the tenant's default connector, e.g. for the sdc_uid of
+// devices onboarded through it.
+func dataSourceSDC() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceSDCRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the connector. Defaults to the tenant's default connector.",
+			},
+			"uid": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"software_version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceSDCRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+	name := d.Get("name").(string)
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL("/connectors"),
+		nil,
+	)
+	if err != nil {
+		return diag.Errorf("Error listing SDCs: %s", err)
+	}
+
+	var connectors []SDC
+	if err := decodeJSON(resp, &connectors); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	var matches []SDC
+	for _, sdc := range connectors {
+		if name == "" && sdc.DefaultConnector || name != "" && sdc.Name == name {
+			matches = append(matches, sdc)
+		}
+	}
+
+	switch {
+	case len(matches) == 1:
+	case name == "" && len(matches) == 0:
+		return diag.Errorf("the tenant has no default SDC, set name to pick one")
+	case name == "":
+		return diag.Errorf("%d default SDCs found, set name to pick one", len(matches))
+	default:
+		return diag.Errorf("%d SDCs named %q found", len(matches), name)
+	}
+
+	sdc := matches[0]
+	d.SetId(string(sdc.Uid))
+	d.Set("uid", string(sdc.Uid))
+	d.Set("name", sdc.Name)
+	d.Set("status", sdc.Status)
+	d.Set("software_version", sdc.SoftwareVersion)
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// dataSourceTransaction exposes what CDO recorded about a transaction, e.g.
+// to report why an onboarding failed.
+func dataSourceTransaction() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceTransactionRead,
+
+		Schema: map[string]*schema.Schema{
+			"transaction_uid": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"stage": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Step a running transaction is at, e.g. PROVISIONING while a device is onboarded, when CDO reports one.",
+			},
+			"entity_uid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "UID of the entity, e.g. the device, the transaction acts on.",
+			},
+			"error_message": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"error_details": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"submission_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_updated_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_active_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceTransactionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+	uid := d.Get("transaction_uid").(string)
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/transactions/%s", uid)),
+		nil,
+	)
+	if err != nil {
+		return diag.Errorf("Error reading transaction: %s", err)
+	}
+
+	var transaction TransactionResponse
+	if err := decodeJSON(resp, &transaction); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	details := make(map[string]string, len(transaction.ErrorDetails))
+	for k, v := range transaction.ErrorDetails {
+		details[k] = fmt.Sprint(v)
+	}
+
+	d.SetId(uid)
+	d.Set("type", transaction.TransactionType)
+	d.Set("status", transaction.CDOTransactionStatus)
+	d.Set("stage", transaction.Stage)
+	d.Set("entity_uid", string(transaction.EntityUid))
+	d.Set("error_message", transaction.ErrorMessage)
+	d.Set("error_details", details)
+	d.Set("submission_time", transaction.SubmissionTime)
+	d.Set("last_updated_time", transaction.LastUpdatedTime)
+	d.Set("last_active_time", transaction.LastActiveTime)
+	return nil
+}
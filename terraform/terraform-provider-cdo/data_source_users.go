@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceUsers() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceUsersRead,
+
+		Schema: map[string]*schema.Schema{
+			"role": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(userRoles, false),
+				Description:  "Only return users with this role.",
+			},
+			"users": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"uid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"role": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"api_only_user": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceUsersRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+	role := d.Get("role").(string)
+
+	users, err := listUsers(ctx, config)
+	if err != nil {
+		return diag.Errorf("Error listing users: %s", err)
+	}
+
+	entries := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		userRole := strings.TrimPrefix(user.Role, rolePrefix)
+		if role != "" && userRole != role {
+			continue
+		}
+		entries = append(entries, map[string]interface{}{
+			"uid":           string(user.Uid),
+			"name":          user.Name,
+			"role":          userRole,
+			"api_only_user": user.ApiOnlyUser,
+		})
+	}
+
+	d.SetId(fmt.Sprintf("users:%s", role))
+	d.Set("users", entries)
+	return nil
+}
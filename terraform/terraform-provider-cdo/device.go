@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// DeviceType describes the inventory endpoints used to onboard and delete one
+// kind of device, so every device resource shares the same code path.
+type DeviceType struct {
+	// Name is used in error messages, e.g. "FTD device".
+	Name string
+	// OnboardPath is the onboarding endpoint, relative to the API root.
+	OnboardPath string
+	// DeletePath is the delete endpoint relative to the API root, with a %s
+	// placeholder for the device UID.
+	DeletePath   string
+	DeleteMethod string
+	// Licenses are the license names the device type accepts, and
+	// DefaultLicenses those requested when a resource doesn't set any.
+	Licenses        []string
+	DefaultLicenses []string
+}
+
+var ftdLicenses = []string{"BASE", "CARRIER", "THREAT", "MALWARE", "URLFilter"}
+
+var ftdDeviceType = DeviceType{
+	Name:            "FTD device",
+	OnboardPath:     "/inventory/devices/ftds/ztp",
+	DeletePath:      "/inventory/devices/ftds/cdfmcManaged/%s/delete",
+	DeleteMethod:    "POST",
+	Licenses:        ftdLicenses,
+	DefaultLicenses: []string{"BASE"},
+}
+
+// registrationKeyFTDDeviceType is a cdFMC managed FTD onboarded with a
+// registration key rather than by serial number. It is deleted like any other
+// cdFMC managed FTD.
+var registrationKeyFTDDeviceType = DeviceType{
+	Name:            "FTD device",
+	OnboardPath:     "/inventory/devices/ftds/cdfmcManaged",
+	DeletePath:      "/inventory/devices/ftds/cdfmcManaged/%s/delete",
+	DeleteMethod:    "POST",
+	Licenses:        ftdLicenses,
+	DefaultLicenses: []string{"BASE"},
+}
+
+// onPremFMCFTDDeviceType is an FTD managed by an on-prem FMC rather than by
+// the cloud-delivered FMC.
+var onPremFMCFTDDeviceType = DeviceType{
+	Name:            "FTD device",
+	OnboardPath:     "/inventory/devices/ftds/onPremFmcManaged/ztp",
+	DeletePath:      "/inventory/devices/ftds/onPremFmcManaged/%s/delete",
+	DeleteMethod:    "POST",
+	Licenses:        ftdLicenses,
+	DefaultLicenses: []string{"BASE"},
+}
+
+// licensesSchema returns the schema of a device resource's licenses
+// attribute, accepting only the given license names.
+func licensesSchema(licenses, defaults []string) *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Optional: true,
+		Computed: true,
+		Elem: &schema.Schema{
+			Type:         schema.TypeString,
+			ValidateFunc: validation.StringInSlice(licenses, false),
+		},
+		Description: fmt.Sprintf("Licenses to request for the device, any of %s. Defaults to %s.", strings.Join(licenses, ", "), strings.Join(defaults, ", ")),
+	}
+}
+
+// deviceLicenses returns the licenses configured on d, or the device type's
+// defaults when there are none.
+func deviceLicenses(d *schema.ResourceData, deviceType DeviceType) []string {
+	var licenses []string
+	for _, v := range d.Get("licenses").(*schema.Set).List() {
+		licenses = append(licenses, v.(string))
+	}
+	if len(licenses) == 0 {
+		return deviceType.DefaultLicenses
+	}
+	sort.Strings(licenses)
+	return licenses
+}
+
+// OnboardingResponse is the response to an onboarding request: the transaction
+// to wait for, plus any data the operator has to apply on the device itself to
+// complete onboarding.
+type OnboardingResponse struct {
+	TransactionResponse
+	BootstrapData   string `json:"bootstrapData"`
+	RegistrationKey string `json:"registrationKey"`
+	CLICommand      string `json:"cliCommand"`
+}
+
+// onboardDevice submits payload to the device type's onboarding endpoint and
+// waits for the resulting transaction. The response is returned as soon as
+// CDO has accepted the request, even when waiting for the transaction fails,
+// so callers can keep track of the partially onboarded device.
+func onboardDevice(ctx context.Context, config *ProviderConfig, deviceType DeviceType, payload interface{}, opts waitOptions) (*OnboardingResponse, error) {
+	release, err := config.acquireTransactionSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	onboarding, err := submitOnboarding(ctx, config, deviceType, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	err = waitFor(ctx, config, onboarding.TransactionPollingURL, opts)
+	return onboarding, err
+}
+
+// submitOnboarding submits payload to the device type's onboarding endpoint
+// without waiting for the resulting transaction.
+func submitOnboarding(ctx context.Context, config *ProviderConfig, deviceType DeviceType, payload interface{}) (*OnboardingResponse, error) {
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		config.apiURL(deviceType.OnboardPath),
+		payload,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating %s: %w", deviceType.Name, err)
+	}
+
+	var onboarding OnboardingResponse
+	if err := decodeJSON(resp, &onboarding); err != nil {
+		return nil, fmt.Errorf("Error parsing response: %s", err)
+	}
+	return &onboarding, nil
+}
+
+// deployDevice deploys the pending configuration changes of a cdFMC managed
+// FTD and waits for the deployment transaction.
+func deployDevice(ctx context.Context, config *ProviderConfig, uid string, opts waitOptions) error {
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		config.apiURL(fmt.Sprintf("/inventory/devices/ftds/cdfmcManaged/%s/deploy", uid)),
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("Error deploying FTD device: %w", err)
+	}
+
+	var transaction TransactionResponse
+	if err := decodeJSON(resp, &transaction); err != nil {
+		return fmt.Errorf("Error parsing response: %s", err)
+	}
+
+	return waitFor(ctx, config, transaction.TransactionPollingURL, opts)
+}
+
+// deleteDevice deletes the device with the given UID and waits for the delete
+// transaction, if the API started one.
+func deleteDevice(ctx context.Context, config *ProviderConfig, deviceType DeviceType, uid string, opts waitOptions) error {
+	release, err := config.acquireTransactionSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	resp, err := makeAPIRequest(
+		ctx,
+		config,
+		deviceType.DeleteMethod,
+		config.apiURL(fmt.Sprintf(deviceType.DeletePath, uid)),
+		nil,
+	)
+	if errors.Is(err, errNotFound) {
+		// Already gone, e.g. deleted outside of Terraform.
+		return nil
+	}
+	if hasStatus(err, http.StatusUnprocessableEntity) && deviceGone(ctx, config, uid) {
+		// Some delete endpoints reject devices they no longer know instead
+		// of reporting them as not found.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Error deleting %s: %w", deviceType.Name, err)
+	}
+
+	transaction, err := deleteTransaction(resp)
+	if err != nil {
+		return err
+	}
+
+	if transaction != nil {
+		return waitFor(ctx, config, transaction.TransactionPollingURL, opts)
+	}
+	return nil
+}
+
+// deviceGone reports whether the inventory confirms that the device with the
+// given UID no longer exists.
+func deviceGone(ctx context.Context, config *ProviderConfig, uid string) bool {
+	_, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/inventory/devices/%s", uid)),
+		nil,
+	)
+	return errors.Is(err, errNotFound)
+}
+
+// deleteTransaction decides how a delete response should be handled. It
+// returns a nil transaction when the delete has already completed, which the
+// API signals with 204 No Content or an empty body, and otherwise the parsed
+// transaction that still has to be polled.
+func deleteTransaction(resp *apiResponse) (*TransactionResponse, error) {
+	if resp.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(resp.Body)) == 0 {
+		return nil, nil
+	}
+
+	var transaction TransactionResponse
+	if err := decodeJSON(resp.Body, &transaction); err != nil {
+		return nil, fmt.Errorf("Error parsing response: %s", err)
+	}
+	return &transaction, nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testTransactionPath = "/api/rest/v1/transactions/tx-1"
+
+// transactionHandler answers the onboarding request with a transaction whose
+// polls return statuses in turn, the last one repeated. The onboarding
+// request's headers and payload are stored in header and payload.
+func transactionHandler(t *testing.T, statuses []string, polls *int32, header *http.Header, payload *map[string]interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/rest/v1"+ftdDeviceType.OnboardPath:
+			*header = r.Header.Clone()
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, payload); err != nil {
+				t.Errorf("onboarding payload %s: %s", body, err)
+			}
+			w.Write([]byte(`{"transactionPollingUrl":"http://` + r.Host + testTransactionPath + `","entityUid":"device-1"}`))
+		case r.Method == "GET" && r.URL.Path == testTransactionPath:
+			n := int(atomic.AddInt32(polls, 1))
+			if n > len(statuses) {
+				n = len(statuses)
+			}
+			w.Write([]byte(`{"cdoTransactionStatus":"` + statuses[n-1] + `","errorMessage":"Registration failed"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestOnboardDevice(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []string
+		wantPolls int32
+		wantState string
+	}{
+		{
+			name:      "done",
+			statuses:  []string{"DONE"},
+			wantPolls: 1,
+			wantState: transactionStateDone,
+		},
+		{
+			name:      "pending then done",
+			statuses:  []string{"PENDING", "PENDING", "IN_PROGRESS", "DONE"},
+			wantPolls: 4,
+			wantState: transactionStateDone,
+		},
+		{
+			name:      "error",
+			statuses:  []string{"PENDING", "ERROR"},
+			wantPolls: 2,
+			wantState: transactionStateError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var polls int32
+			var header http.Header
+			var payload map[string]interface{}
+			config := newTestConfig(t, transactionHandler(t, tt.statuses, &polls, &header, &payload))
+
+			opts := cdoTransactionWait
+			opts.Interval = time.Millisecond
+			opts.MaxAttempts = 10
+			onboarding, err := onboardDevice(context.Background(), config, ftdDeviceType, map[string]interface{}{
+				"name":         "ftd-1",
+				"serialNumber": "JAD1234",
+				"licenses":     []string{"BASE"},
+			}, opts)
+
+			if got := transactionState(err); got != tt.wantState {
+				t.Errorf("transactionState(%v) = %s, want %s", err, got, tt.wantState)
+			}
+			if onboarding == nil || onboarding.EntityUid != "device-1" {
+				t.Fatalf("onboardDevice() = %+v, want entity device-1", onboarding)
+			}
+			if polls != tt.wantPolls {
+				t.Errorf("got %d polls, want %d", polls, tt.wantPolls)
+			}
+			if got := header.Get("Authorization"); got != "Bearer token" {
+				t.Errorf("Authorization = %q, want the configured token", got)
+			}
+			if got := header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			want := map[string]interface{}{
+				"name":         "ftd-1",
+				"serialNumber": "JAD1234",
+				"licenses":     []interface{}{"BASE"},
+			}
+			if !reflect.DeepEqual(payload, want) {
+				t.Errorf("payload = %v, want %v", payload, want)
+			}
+		})
+	}
+}
+
+func TestDeleteDeviceWithoutTransaction(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "no content", status: http.StatusNoContent},
+		{name: "empty body", status: http.StatusOK},
+		{name: "not found", status: http.StatusNotFound, body: `{"errorCode":"NOT_FOUND"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				if r.Method != "POST" || r.URL.Path != "/api/rest/v1/inventory/devices/ftds/cdfmcManaged/device-1/delete" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+
+			if err := deleteDevice(context.Background(), config, ftdDeviceType, "device-1", cdoTransactionWait); err != nil {
+				t.Fatalf("deleteDevice() error = %s", err)
+			}
+			if calls != 1 {
+				t.Errorf("got %d requests, want only the delete and no polls", calls)
+			}
+		})
+	}
+}
+
+func TestOnboardDeviceUsesAPIVersion(t *testing.T) {
+	var config *ProviderConfig
+	var paths []string
+	config = newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		if r.Method == "POST" {
+			w.Write([]byte(`{"transactionPollingUrl":"` + config.apiURL("/transactions/tx-1") + `","entityUid":"device-1"}`))
+			return
+		}
+		w.Write([]byte(`{"cdoTransactionStatus":"DONE"}`))
+	}))
+	config.APIVersion = "v2"
+
+	if _, err := onboardDevice(context.Background(), config, ftdDeviceType, map[string]interface{}{"name": "ftd-1"}, cdoTransactionWait); err != nil {
+		t.Fatalf("onboardDevice() error = %s", err)
+	}
+
+	want := []string{
+		"POST /api/rest/v2/inventory/devices/ftds/ztp",
+		"GET /api/rest/v2/transactions/tx-1",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("requests = %v, want %v", paths, want)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// errorDiagnostics returns err as an error diagnostic with the given summary,
+// adding a hint on how to resolve the failures that have one.
+func errorDiagnostics(summary string, err error) diag.Diagnostics {
+	detail := err.Error()
+	if hint := remediationHint(err); hint != "" {
+		detail = fmt.Sprintf("%s\n\n%s", detail, hint)
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  summary,
+		Detail:   detail,
+	}}
+}
+
+// remediationHint tells what to do about err, based on its type. errUnauthorized
+// carries its own hint.
+func remediationHint(err error) string {
+	var authErr *authError
+	var rateErr *rateLimitError
+	var txErr *transactionError
+
+	switch {
+	case errors.As(err, &authErr) && authErr.StatusCode == http.StatusForbidden:
+		return "The CDO user the provider authenticates as isn't allowed to do this. Use the credentials of a user whose role is, e.g. ADMIN or SUPER_ADMIN."
+	case errors.As(err, &rateErr):
+		return "CDO is rate limiting requests from this tenant. Lower the provider's requests_per_second or max_concurrent_operations, or run Terraform with a lower -parallelism."
+	case errors.As(err, &txErr) && txErr.TimedOut:
+		return "The transaction may still complete on the CDO side. Raise poll_timeout_seconds or the resource's timeouts to wait for it longer."
+	case errors.As(err, &txErr) && txErr.Transaction.TransactionUid != "":
+		return fmt.Sprintf("The cdo_transaction data source with transaction_uid = %q returns what CDO recorded about the failure.", txErr.Transaction.TransactionUid)
+	case isTemporary(err):
+		return "CDO may be temporarily unavailable, running the apply again usually succeeds. max_retries controls how often failed requests are retried."
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const inventoryPageSize = 200
+
+// Device is the device-type independent view of an inventory entry.
+type Device struct {
+	Uid               flexString `json:"uid"`
+	Name              string     `json:"name"`
+	SerialNumber      string     `json:"serial"`
+	DeviceType        string     `json:"deviceType"`
+	ConnectivityState string     `json:"connectivityState"`
+	Labels            Labels     `json:"labels"`
+}
+
+type inventoryPage struct {
+	Count flexInt           `json:"count"`
+	Items []json.RawMessage `json:"items"`
+}
+
+// listInventory returns the raw inventory entries matching query, following
+// the pagination until every entry has been fetched. An empty query lists the
+// whole inventory.
+func listInventory(ctx context.Context, config *ProviderConfig, query string) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+
+	for offset := 0; ; offset += inventoryPageSize {
+		params := url.Values{}
+		params.Set("limit", fmt.Sprint(inventoryPageSize))
+		params.Set("offset", fmt.Sprint(offset))
+		if query != "" {
+			params.Set("q", query)
+		}
+
+		resp, err := makeRequest(
+			ctx,
+			config,
+			"GET",
+			config.apiURL(fmt.Sprintf("/inventory/devices?%s", params.Encode())),
+			nil,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		var page inventoryPage
+		if err := decodeJSON(resp, &page); err != nil {
+			return nil, fmt.Errorf("Error parsing response: %s", err)
+		}
+
+		items = append(items, page.Items...)
+		if len(page.Items) == 0 || len(items) >= int(page.Count) {
+			return items, nil
+		}
+	}
+}
+
+// listDevices returns the devices matching query. Plural resources read all
+// of their devices from one listing rather than requesting them one by one.
+func listDevices(ctx context.Context, config *ProviderConfig, query string) ([]Device, error) {
+	items, err := listInventory(ctx, config, query)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]Device, 0, len(items))
+	for _, item := range items {
+		var device Device
+		if err := decodeJSON(item, &device); err != nil {
+			return nil, fmt.Errorf("Error parsing response: %s", err)
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// inventoryQuery builds an exact-match inventory query for a single field.
+func inventoryQuery(field, value string) string {
+	return fmt.Sprintf("%s:\"%s\"", field, strings.ReplaceAll(value, `"`, `\"`))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// decodeJSON unmarshals an API response. Numbers decoded into interface{}
+// values are kept as json.Number so large numeric IDs don't lose precision.
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// flexString is a string field, such as a UID, that CDO sometimes sends as a
+// JSON number.
+type flexString string
+
+func (s *flexString) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var str string
+		if err := json.Unmarshal(b, &str); err != nil {
+			return err
+		}
+		*s = flexString(str)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("expected a string or number, got %s", b)
+	}
+	*s = flexString(n.String())
+	return nil
+}
+
+// flexInt is an integer field that CDO sometimes sends as a JSON string.
+type flexInt int
+
+func (i *flexInt) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+
+	n, err := strconv.Atoi(string(bytes.Trim(b, `"`)))
+	if err != nil {
+		return fmt.Errorf("expected an integer, got %s", b)
+	}
+	*i = flexInt(n)
+	return nil
+}
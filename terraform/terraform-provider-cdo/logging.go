@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+
+	defaultLogBodyMaxBytes = 4096
+)
+
+// sensitiveBodyFields are JSON keys whose values are never written to logs.
+var sensitiveBodyFields = map[string]struct{}{
+	"adminpassword":     {},
+	"apitoken":          {},
+	"bootstrapdata":     {},
+	"clicommand":        {},
+	"password":          {},
+	"registrationkey":   {},
+	"smartlicensetoken": {},
+	"webhookurl":        {},
+}
+
+// sensitiveHeaders are request headers whose values are never written to
+// logs.
+var sensitiveHeaders = map[string]struct{}{
+	"Authorization": {},
+}
+
+// logAPIEvent writes a provider log line carrying the given fields. By
+// default it goes through tflog, so the entries show up with TF_LOG and
+// TF_LOG_PROVIDER like any other provider log. With log_format = "json" the
+// message and fields are emitted as a single JSON object so log pipelines
+// can ingest them without scraping text.
+func logAPIEvent(ctx context.Context, config *ProviderConfig, level, message string, fields map[string]interface{}) {
+	if config.LogFormat != logFormatJSON {
+		// Never let the token through, whichever field it ends up in.
+		if token := config.Token(); token != "" {
+			ctx = tflog.MaskAllFieldValuesStrings(ctx, token)
+		}
+
+		switch level {
+		case "TRACE":
+			tflog.Trace(ctx, message, fields)
+		case "DEBUG":
+			tflog.Debug(ctx, message, fields)
+		case "INFO":
+			tflog.Info(ctx, message, fields)
+		case "WARN":
+			tflog.Warn(ctx, message, fields)
+		default:
+			tflog.Error(ctx, message, fields)
+		}
+		return
+	}
+
+	entry := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		entry[k] = v
+	}
+	entry["message"] = message
+
+	line, err := json.Marshal(entry)
+	if err == nil {
+		log.Printf("[%s] %s", level, line)
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, fields[k]))
+	}
+	log.Printf("[%s] %s: %s", level, message, strings.Join(pairs, " "))
+}
+
+// loggableBody prepares a request or response body for logging: sensitive
+// JSON fields are redacted and the result is cut to log_body_max_bytes. An
+// empty string is returned when body logging is disabled.
+func loggableBody(config *ProviderConfig, body []byte) string {
+	if config.LogBodyMaxBytes <= 0 || len(body) == 0 {
+		return ""
+	}
+
+	var decoded interface{}
+	if err := decodeJSON(body, &decoded); err == nil {
+		if redacted, err := json.Marshal(redactFields(decoded)); err == nil {
+			body = redacted
+		}
+	}
+
+	if len(body) > config.LogBodyMaxBytes {
+		return fmt.Sprintf("%s...(%d bytes truncated)", body[:config.LogBodyMaxBytes], len(body)-config.LogBodyMaxBytes)
+	}
+	return string(body)
+}
+
+// loggableHeaders returns the request headers with sensitive values
+// redacted.
+func loggableHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for k := range header {
+		if _, ok := sensitiveHeaders[http.CanonicalHeaderKey(k)]; ok {
+			headers[k] = "***"
+			continue
+		}
+		headers[k] = header.Get(k)
+	}
+	return headers
+}
+
+func redactFields(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if _, ok := sensitiveBodyFields[strings.ToLower(k)]; ok {
+				v[k] = "***"
+				continue
+			}
+			v[k] = redactFields(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactFields(item)
+		}
+	}
+	return v
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoggableBodyRedactsSensitiveFields(t *testing.T) {
+	config := &ProviderConfig{LogBodyMaxBytes: defaultLogBodyMaxBytes}
+
+	tests := []struct {
+		name   string
+		body   string
+		secret string
+		want   string
+	}{
+		{
+			name:   "ASA onboarding",
+			body:   `{"name":"asa-1","deviceAddress":"10.0.0.1:443","username":"admin","password":"hunter22","ignoreCertificate":false}`,
+			secret: "hunter22",
+			want:   `"password":"***"`,
+		},
+		{
+			name:   "IOS onboarding",
+			body:   `{"name":"ios-1","deviceAddress":"10.0.0.2:22","username":"admin","password":"c1sco123","connectorName":"sdc-1"}`,
+			secret: "c1sco123",
+			want:   `"password":"***"`,
+		},
+		{
+			name:   "nested in a list",
+			body:   `{"items":[{"name":"ftd-1","adminPassword":"Sup3rSecret!"}]}`,
+			secret: "Sup3rSecret!",
+			want:   `"adminPassword":"***"`,
+		},
+		{
+			name:   "key case doesn't matter",
+			body:   `{"Password":"hunter22"}`,
+			secret: "hunter22",
+			want:   `"Password":"***"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := loggableBody(config, []byte(tt.body))
+			if strings.Contains(got, tt.secret) {
+				t.Errorf("loggableBody() = %s, leaks %q", got, tt.secret)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("loggableBody() = %s, want it to contain %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoggableBodyKeepsOtherFields(t *testing.T) {
+	config := &ProviderConfig{LogBodyMaxBytes: defaultLogBodyMaxBytes}
+
+	got := loggableBody(config, []byte(`{"name":"asa-1","username":"admin","password":"hunter22"}`))
+	for _, want := range []string{`"name":"asa-1"`, `"username":"admin"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("loggableBody() = %s, want it to contain %s", got, want)
+		}
+	}
+}
+
+func TestLoggableBodyDisabled(t *testing.T) {
+	config := &ProviderConfig{LogBodyMaxBytes: 0}
+
+	if got := loggableBody(config, []byte(`{"password":"hunter22"}`)); got != "" {
+		t.Errorf("loggableBody() = %q, want no body with log_body_max_bytes = 0", got)
+	}
+}
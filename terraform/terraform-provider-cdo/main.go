@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
 )
 
@@ -15,31 +20,367 @@ func main() {
 }
 
 func Provider() *schema.Provider {
-	return &schema.Provider{
+	provider := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"base_url": {
 				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("CDO_BASE_URL", "https://edge.staging.cdo.cisco.com"),
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_BASE_URL", ""),
+				Description: "URL of the CDO API, for endpoints that aren't covered by region. Conflicts with region.",
+			},
+			"region": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_REGION", ""),
+				Description: fmt.Sprintf("CDO region of the tenant, one of %s. Defaults to %s when base_url isn't set either. Conflicts with base_url.", strings.Join(regionNames(), ", "), defaultRegion),
 			},
 			"token": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				Sensitive:   true,
-				DefaultFunc: schema.EnvDefaultFunc("CDO_TOKEN", nil),
+				DefaultFunc: schema.EnvDefaultFunc("CDO_TOKEN", ""),
+				Description: "CDO API token. Either a token or a username and password must be set.",
+			},
+			"username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_USERNAME", ""),
+				Description: "Username of a CDO API user, exchanged together with password for a token when no token is set. The token is renewed when it expires.",
+			},
+			"password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_PASSWORD", ""),
+				Description: "Password of the CDO API user set in username.",
+			},
+			"api_version": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_API_VERSION", defaultAPIVersion),
+				Description: "Version of the CDO REST API to use, e.g. \"v1\". Every endpoint is under /api/rest/<api_version> of base_url.",
+			},
+			"fail_on_unknown_transaction_status": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_FAIL_ON_UNKNOWN_TRANSACTION_STATUS", false),
+				Description: "Fail immediately when a transaction reports a status the provider does not recognize, instead of logging a warning and continuing to poll.",
+			},
+			"log_format": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CDO_LOG_FORMAT", logFormatText),
+				ValidateFunc: validation.StringInSlice([]string{logFormatText, logFormatJSON}, false),
+				Description:  "Format of the provider's API log lines: \"text\" logs structured entries through Terraform's logger, \"json\" writes one JSON object per line.",
+			},
+			"log_body_max_bytes": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_LOG_BODY_MAX_BYTES", defaultLogBodyMaxBytes),
+				Description: "Maximum number of bytes of each request and response body written to debug logs. Set to 0 to disable body logging.",
+			},
+			"on_success_webhook": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_ON_SUCCESS_WEBHOOK", ""),
+				Description: "URL that receives a JSON summary after each device is onboarded successfully.",
+			},
+			"on_failure_webhook": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_ON_FAILURE_WEBHOOK", ""),
+				Description: "URL that receives a JSON summary after a device fails to onboard.",
+			},
+			"accept_language": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_ACCEPT_LANGUAGE", ""),
+				Description: "Value of the Accept-Language header sent with every request, used by CDO to localize error messages.",
+			},
+			"read_only": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_READ_ONLY", false),
+				Description: "Refuse to create, update or delete anything in CDO. Reads and data sources keep working, which makes it safe to plan against production to detect drift.",
+			},
+			"offline": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_OFFLINE", false),
+				Description: "Plan without network access to CDO, e.g. from an air-gapped network. Resources keep the state of the last refresh instead of being read, no credentials are needed, and data sources that query CDO, as well as any apply, fail. Plans made offline can't detect drift.",
+			},
+			"prevent_device_replacement": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_PREVENT_DEVICE_REPLACEMENT", false),
+				Description: "Fail the plan when a change would replace an onboarded device, unless the resource sets allow_replacement.",
+			},
+			"compress_requests": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_COMPRESS_REQUESTS", false),
+				Description: "Gzip request bodies. Only enable this for tenants whose endpoints accept compressed uploads.",
+			},
+			"workspace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TF_WORKSPACE", ""),
+				Description: "Terraform workspace sent with every request so CDO audit logs can be correlated with it. Typically set to terraform.workspace.",
+			},
+			"read_timeout_seconds": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_READ_TIMEOUT_SECONDS", int(defaultReadTimeout.Seconds())),
+				Description: "HTTP timeout for read requests, including transaction status checks.",
+			},
+			"write_timeout_seconds": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_WRITE_TIMEOUT_SECONDS", int(defaultWriteTimeout.Seconds())),
+				Description: "HTTP timeout for onboarding, update and deployment requests.",
+			},
+			"delete_timeout_seconds": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_DELETE_TIMEOUT_SECONDS", int(defaultDeleteTimeout.Seconds())),
+				Description: "HTTP timeout for delete requests.",
+			},
+			"poll_interval_seconds": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_POLL_INTERVAL_SECONDS", int(defaultPollInterval.Seconds())),
+				Description: "Delay between two checks of a running CDO transaction.",
+			},
+			"poll_timeout_seconds": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_POLL_TIMEOUT_SECONDS", 0),
+				Description: "How long to wait for a CDO transaction to finish, when shorter than the resource's own timeouts. Defaults to 0, which waits for as long as the resource's timeouts allow.",
+			},
+			"poll_max_interval_seconds": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_POLL_MAX_INTERVAL_SECONDS", 0),
+				Description: "Upper bound for the delay between checks of a running CDO transaction. When greater than poll_interval_seconds, the delay doubles after every check up to this value. Defaults to polling at a fixed interval.",
+			},
+			"pinned_cert_sha256": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_PINNED_CERT_SHA256", ""),
+				Description: "SHA-256 fingerprint of the certificate the CDO endpoint must present. When set, connections to any other certificate are refused.",
+			},
+			"ca_certificate": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_CA_CERTIFICATE", ""),
+				Description: "PEM encoded CA certificates, or the path to a PEM bundle, used to verify the CDO endpoint instead of the system roots, e.g. the CA of a TLS intercepting proxy.",
+			},
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_INSECURE_SKIP_VERIFY", false),
+				Description: "Skip verification of the CDO endpoint's certificate. Only meant for testing, a warning is shown whenever it is set; prefer ca_certificate behind a TLS intercepting proxy.",
+			},
+			"proxy_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_PROXY_URL", ""),
+				Description: "Proxy used for API requests and webhook notifications. Defaults to the proxy set in the HTTPS_PROXY and NO_PROXY environment variables.",
+			},
+			"requests_per_second": {
+				Type:        schema.TypeFloat,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_REQUESTS_PER_SECOND", 0.0),
+				Description: "Maximum rate of API requests across all resources of this provider configuration. Each provider alias has its own budget. 0 means unlimited.",
+			},
+			"default_access_policy_uuid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CDO_DEFAULT_ACCESS_POLICY_UUID", ""),
+				ValidateFunc: validation.Any(validation.StringIsEmpty, validation.IsUUID),
+				Description:  "Access policy assigned to devices that don't set their own access_policy_uuid.",
+			},
+			"max_concurrent_operations": {
+				Type:          schema.TypeInt,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("CDO_MAX_CONCURRENT_OPERATIONS", 0),
+				ValidateFunc:  validation.IntAtLeast(0),
+				ConflictsWith: []string{"max_concurrent_transactions"},
+				Description:   "Maximum number of create and delete operations, e.g. device onboarding or removing an MSP tenant, run at the same time across all resources of this provider configuration. 0 means unlimited, leaving Terraform's -parallelism as the only limit; set it to 1 to run them one at a time while reads and updates still run in parallel.",
+			},
+			"max_concurrent_transactions": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_MAX_CONCURRENT_TRANSACTIONS", 0),
+				Deprecated:  "Use max_concurrent_operations, which sets the same limit.",
+				Description: "Former name of max_concurrent_operations.",
+			},
+			"max_retries": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_MAX_RETRIES", defaultMaxRetries),
+				Description: "How many times a request failing with a connection error or a retryable status is retried, with exponential backoff. Requests that create something, such as onboarding, are only retried when they can't have reached CDO: when connecting failed or CDO answered 429.",
+			},
+			"retry_wait_seconds": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CDO_RETRY_WAIT_SECONDS", int(defaultRetryWait.Seconds())),
+				Description: "Delay before the first retry of a failed request. It doubles with every further retry, up to 30 seconds.",
+			},
+			"retry_status_codes": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Description: "HTTP status codes that are retried. Defaults to 429, 500, 502, 503 and 504.",
+			},
+			"retryable_error_substrings": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Response body substrings that cause a request to be retried even when it succeeded with a 2xx status.",
 			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"cdo_ftd_device": resourceFTDDevice(),
+			"cdo_api_token":                 resourceAPIToken(),
+			"cdo_asa_device":                resourceASADevice(),
+			"cdo_cdfmc_device_record":       resourceCDFMCDeviceRecord(),
+			"cdo_cloud_account":             resourceCloudAccount(),
+			"cdo_device_group":              resourceDeviceGroup(),
+			"cdo_ftd_device":                resourceFTDDevice(),
+			"cdo_ftd_device_deployment":     resourceFTDDeviceDeployment(),
+			"cdo_ftd_devices_batch":         resourceFTDDevicesBatch(),
+			"cdo_ios_device":                resourceIOSDevice(),
+			"cdo_msp_tenant":                resourceMSPTenant(),
+			"cdo_msp_tenant_user":           resourceMSPTenantUser(),
+			"cdo_device_sync":               resourceDeviceSync(),
+			"cdo_network_object":            resourceNetworkObject(),
+			"cdo_notification_subscription": resourceNotificationSubscription(),
+			"cdo_network_object_group":      resourceNetworkObjectGroup(),
+			"cdo_policy_assignment":         resourcePolicyAssignment(),
+			"cdo_sdc":                       resourceSDC(),
+			"cdo_user":                      resourceUser(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"cdo_access_policy":  dataSourceAccessPolicy(),
+			"cdo_cdfmc":          dataSourceCDFMC(),
+			"cdo_device_audit":   dataSourceDeviceAudit(),
+			"cdo_device_health":  dataSourceDeviceHealth(),
+			"cdo_device_group":   dataSourceDeviceGroup(),
+			"cdo_devices":        dataSourceDevices(),
+			"cdo_ftd_device":     dataSourceFTDDevice(),
+			"cdo_ftd_ztp_config": dataSourceFTDZTPConfig(),
+			"cdo_sdc":            dataSourceSDC(),
+			"cdo_transaction":    dataSourceTransaction(),
+			"cdo_inventory_raw":  dataSourceInventoryRaw(),
+			"cdo_users":          dataSourceUsers(),
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
+
+	for _, r := range provider.ResourcesMap {
+		r.ReadContext = offlineRead(r.ReadContext)
+	}
+	return provider
 }
 
-func providerConfigure(_ context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+// offlineRead wraps the read function of a resource so that, when the provider
+// is offline, it keeps the resource's state as it is instead of reading it.
+func offlineRead(read schema.ReadContextFunc) schema.ReadContextFunc {
+	if read == nil {
+		return nil
+	}
+	return func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+		if config, ok := m.(*ProviderConfig); ok && config.Offline {
+			log.Printf("[DEBUG] Provider is offline, keeping the state of %s", d.Id())
+			return nil
+		}
+		return read(ctx, d, m)
+	}
+}
+
+func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	config := &ProviderConfig{
-		BaseURL: d.Get("base_url").(string),
-		Token:   d.Get("token").(string),
+		BaseURL:                        d.Get("base_url").(string),
+		Region:                         d.Get("region").(string),
+		APIVersion:                     d.Get("api_version").(string),
+		Username:                       d.Get("username").(string),
+		Password:                       d.Get("password").(string),
+		FailOnUnknownTransactionStatus: d.Get("fail_on_unknown_transaction_status").(bool),
+		LogFormat:                      d.Get("log_format").(string),
+		LogBodyMaxBytes:                d.Get("log_body_max_bytes").(int),
+		DefaultAccessPolicyUUID:        d.Get("default_access_policy_uuid").(string),
+		OnSuccessWebhook:               d.Get("on_success_webhook").(string),
+		OnFailureWebhook:               d.Get("on_failure_webhook").(string),
+		AcceptLanguage:                 d.Get("accept_language").(string),
+		ReadOnly:                       d.Get("read_only").(bool),
+		Offline:                        d.Get("offline").(bool),
+		PreventDeviceReplacement:       d.Get("prevent_device_replacement").(bool),
+		CompressRequests:               d.Get("compress_requests").(bool),
+		Workspace:                      d.Get("workspace").(string),
+		ReadTimeout:                    time.Duration(d.Get("read_timeout_seconds").(int)) * time.Second,
+		WriteTimeout:                   time.Duration(d.Get("write_timeout_seconds").(int)) * time.Second,
+		DeleteTimeout:                  time.Duration(d.Get("delete_timeout_seconds").(int)) * time.Second,
+		PollInterval:                   time.Duration(d.Get("poll_interval_seconds").(int)) * time.Second,
+		PollTimeout:                    time.Duration(d.Get("poll_timeout_seconds").(int)) * time.Second,
+		PollMaxInterval:                time.Duration(d.Get("poll_max_interval_seconds").(int)) * time.Second,
+		PinnedCertSHA256:               d.Get("pinned_cert_sha256").(string),
+		CACertificate:                  d.Get("ca_certificate").(string),
+		InsecureSkipVerify:             d.Get("insecure_skip_verify").(bool),
+		ProxyURL:                       d.Get("proxy_url").(string),
+		RequestsPerSecond:              d.Get("requests_per_second").(float64),
+		MaxRetries:                     d.Get("max_retries").(int),
+		RetryWait:                      time.Duration(d.Get("retry_wait_seconds").(int)) * time.Second,
+		MaxConcurrentTransactions:      d.Get("max_concurrent_transactions").(int),
+	}
+	if v := d.Get("max_concurrent_operations").(int); v != 0 {
+		config.MaxConcurrentTransactions = v
 	}
-	return config, nil
-}
\ No newline at end of file
+
+	config.SetToken(d.Get("token").(string))
+	config.TokenSource = staticTokenSource{}
+	if config.Token() == "" && config.Username != "" {
+		config.TokenSource = &credentialsTokenSource{config: config}
+	}
+
+	config.RetryStatusCodes = defaultRetryStatusCodes
+	if v, ok := d.GetOk("retry_status_codes"); ok {
+		config.RetryStatusCodes = nil
+		for _, code := range v.([]interface{}) {
+			config.RetryStatusCodes = append(config.RetryStatusCodes, code.(int))
+		}
+	}
+
+	for _, substring := range d.Get("retryable_error_substrings").([]interface{}) {
+		config.RetryableErrorSubstrings = append(config.RetryableErrorSubstrings, substring.(string))
+	}
+
+	diags := config.Validate()
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	if config.BaseURL == "" {
+		config.BaseURL = regionBaseURL(config.Region)
+	}
+	config.HTTPClient = newHTTPClient(config)
+	if config.Offline {
+		// Nothing is sent to CDO, so there are no credentials to check.
+		return config, diags
+	}
+	if config.Token() == "" {
+		if err := config.refreshToken(ctx, ""); err != nil {
+			return nil, append(diags, diag.Errorf("Error authenticating to CDO as %q: %s", config.Username, err)...)
+		}
+	}
+	if config.MaxConcurrentTransactions > 0 {
+		config.transactionSlots = make(chan struct{}, config.MaxConcurrentTransactions)
+	}
+	if config.RequestsPerSecond > 0 {
+		config.limiter = newTokenBucket(config.RequestsPerSecond)
+	}
+
+	if err := checkCredentials(ctx, config); err != nil {
+		return nil, append(diags, credentialsError(config, err))
+	}
+	return config, diags
+}
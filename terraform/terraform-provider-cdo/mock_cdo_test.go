@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// mockCDO is an in-memory stand-in for the CDO API, for unit tests of
+// resource CRUD functions. JSON objects POSTed to a collection get a uid and
+// can then be read, patched and deleted at <collection>/<uid>. Endpoints that
+// don't follow that pattern are set up with Handle.
+type mockCDO struct {
+	mu       sync.Mutex
+	objects  map[string]map[string]interface{}
+	handlers map[string]func(m *mockCDO, body map[string]interface{}) (int, interface{})
+	nextUID  int
+	requests []string
+	bodies   map[string]map[string]interface{}
+}
+
+// newMockCDO starts a mock CDO API and returns a provider configuration that
+// talks to it.
+func newMockCDO(t *testing.T) (*mockCDO, *ProviderConfig) {
+	t.Helper()
+
+	m := &mockCDO{
+		objects:  map[string]map[string]interface{}{},
+		handlers: map[string]func(*mockCDO, map[string]interface{}) (int, interface{}){},
+		bodies:   map[string]map[string]interface{}{},
+	}
+	return m, newTestConfig(t, m)
+}
+
+// Handle overrides the response to requests with the given method and API
+// path, e.g. "GET", "/inventory/devices". The handler is called with the lock
+// held and can use Object to read or change stored objects.
+func (m *mockCDO) Handle(method, path string, handler func(m *mockCDO, body map[string]interface{}) (int, interface{})) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[method+" "+path] = handler
+}
+
+// Object returns the object stored at path, or nil.
+func (m *mockCDO) Object(path string) map[string]interface{} {
+	return m.objects[path]
+}
+
+// Requests returns the requests received so far, as "METHOD path".
+func (m *mockCDO) Requests() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.requests...)
+}
+
+// Body returns the body of the last request with the given method and API
+// path, or nil.
+func (m *mockCDO) Body(method, path string) map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bodies[method+" "+path]
+}
+
+func (m *mockCDO) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/rest/"+defaultAPIVersion)
+	m.requests = append(m.requests, r.Method+" "+path)
+
+	// Bodies that aren't a JSON object, such as bulk requests, reach
+	// handlers as nil.
+	var body map[string]interface{}
+	if b, _ := io.ReadAll(r.Body); len(b) > 0 {
+		var decoded interface{}
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, _ = decoded.(map[string]interface{})
+	}
+	m.bodies[r.Method+" "+path] = body
+
+	status, resp := m.serve(r.Method, path, body)
+	w.WriteHeader(status)
+	if resp != nil {
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (m *mockCDO) serve(method, path string, body map[string]interface{}) (int, interface{}) {
+	if handler, ok := m.handlers[method+" "+path]; ok {
+		return handler(m, body)
+	}
+
+	switch method {
+	case "POST":
+		if body == nil {
+			body = map[string]interface{}{}
+		}
+		m.nextUID++
+		uid := fmt.Sprintf("00000000-0000-0000-0000-%012d", m.nextUID)
+		body["uid"] = uid
+		m.objects[path+"/"+uid] = body
+		return http.StatusCreated, body
+	case "GET":
+		if object, ok := m.objects[path]; ok {
+			return http.StatusOK, object
+		}
+	case "PUT", "PATCH":
+		if object, ok := m.objects[path]; ok {
+			for k, v := range body {
+				object[k] = v
+			}
+			return http.StatusOK, object
+		}
+	case "DELETE":
+		if _, ok := m.objects[path]; ok {
+			delete(m.objects, path)
+			return http.StatusNoContent, nil
+		}
+	}
+	return http.StatusNotFound, map[string]string{"errorCode": "NOT_FOUND", "errorMessage": path + " not found"}
+}
+
+// mockApply plans and applies raw as the configuration of a resource of type
+// r currently in state, which is nil for a new resource, the way Terraform
+// would, and returns the new state.
+func mockApply(t *testing.T, r *schema.Resource, state *terraform.InstanceState, raw map[string]interface{}, config *ProviderConfig) *terraform.InstanceState {
+	t.Helper()
+
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), config)
+	if err != nil {
+		t.Fatalf("Diff() error = %s", err)
+	}
+	newState, diags := r.Apply(context.Background(), state, diff, config)
+	if diags.HasError() {
+		t.Fatalf("Apply() error = %v", diags)
+	}
+	return newState
+}
+
+// mockDestroy destroys a resource of type r in state.
+func mockDestroy(t *testing.T, r *schema.Resource, state *terraform.InstanceState, config *ProviderConfig) {
+	t.Helper()
+
+	if _, diags := r.Apply(context.Background(), state, &terraform.InstanceDiff{Destroy: true}, config); diags.HasError() {
+		t.Fatalf("Apply() error = %v", diags)
+	}
+}
@@ -1,6 +1,372 @@
 package main
 
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+const defaultAPIVersion = "v1"
+
+var apiVersionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// regionBaseURLs maps the CDO regions to their API URLs, and defaultRegion is
+// used when neither region nor base_url are configured.
+var regionBaseURLs = map[string]string{
+	"us":      "https://www.defenseorchestrator.com",
+	"eu":      "https://www.defenseorchestrator.eu",
+	"apj":     "https://apj.cdo.cisco.com",
+	"staging": "https://edge.staging.cdo.cisco.com",
+	"scale":   "https://edge.scale.cdo.cisco.com",
+}
+
+const defaultRegion = "staging"
+
+// regionNames returns the names of the CDO regions in a stable order.
+func regionNames() []string {
+	names := make([]string, 0, len(regionBaseURLs))
+	for name := range regionBaseURLs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// regionBaseURL returns the API URL of region, or of defaultRegion when
+// region is empty.
+func regionBaseURL(region string) string {
+	if region == "" {
+		region = defaultRegion
+	}
+	return regionBaseURLs[region]
+}
+
 type ProviderConfig struct {
-	BaseURL string
-	Token   string
-}
\ No newline at end of file
+	// BaseURL is the configured base_url until providerConfigure resolves
+	// it from Region when it's empty.
+	BaseURL    string
+	Region     string
+	APIVersion string
+
+	// token is read by every request and may be replaced while requests
+	// from parallel resources are in flight, so it is only accessed through
+	// Token and SetToken.
+	tokenMu sync.RWMutex
+	token   string
+
+	// TokenSource is asked for a new token when the API rejects the current
+	// one. providerConfigure sets it to a credentialsTokenSource when the
+	// provider logs in with Username and Password, and otherwise to a
+	// staticTokenSource, which can't.
+	TokenSource TokenSource
+	Username    string
+	Password    string
+
+	FailOnUnknownTransactionStatus bool
+	LogFormat                      string
+	DefaultAccessPolicyUUID        string
+	RetryStatusCodes               []int
+	RetryableErrorSubstrings       []string
+	LogBodyMaxBytes                int
+	OnSuccessWebhook               string
+	OnFailureWebhook               string
+	AcceptLanguage                 string
+	ReadOnly                       bool
+	Offline                        bool
+	PreventDeviceReplacement       bool
+	CompressRequests               bool
+	Workspace                      string
+	ReadTimeout                    time.Duration
+	WriteTimeout                   time.Duration
+	DeleteTimeout                  time.Duration
+	PollInterval                   time.Duration
+	PollTimeout                    time.Duration
+	PollMaxInterval                time.Duration
+	PinnedCertSHA256               string
+	CACertificate                  string
+	InsecureSkipVerify             bool
+	ProxyURL                       string
+	RequestsPerSecond              float64
+	MaxRetries                     int
+	RetryWait                      time.Duration
+	MaxConcurrentTransactions      int
+
+	// transactionSlots holds one token per running create or delete
+	// transaction when MaxConcurrentTransactions is set; nil means no limit.
+	transactionSlots chan struct{}
+
+	// limiter spends the request budget of RequestsPerSecond, separately for
+	// every provider configuration; nil means no limit.
+	limiter *tokenBucket
+
+	// HTTPClient sends every API request. providerConfigure sets it up from
+	// the rest of the configuration; it can be replaced, e.g. to use a fake
+	// transport.
+	HTTPClient *http.Client
+
+	// RequestInterceptor, when set, is called with every API request just
+	// before it is sent. It can add headers, sign or capture requests, and
+	// aborts the request by returning an error.
+	RequestInterceptor func(*http.Request) error
+}
+
+// acquireTransactionSlot blocks until another create or delete transaction
+// may start, or ctx is done, so that parallel resources don't trip the
+// tenant's rate limits. The returned function releases the slot and must
+// always be called once the transaction is over.
+func (c *ProviderConfig) acquireTransactionSlot(ctx context.Context) (func(), error) {
+	if c.transactionSlots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case c.transactionSlots <- struct{}{}:
+		return func() { <-c.transactionSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// apiURL returns the URL of an endpoint of the configured API version, given
+// its path relative to the API root, e.g. "/connectors".
+func (c *ProviderConfig) apiURL(path string) string {
+	return fmt.Sprintf("%s/api/rest/%s%s", c.BaseURL, c.APIVersion, path)
+}
+
+// httpClient returns HTTPClient, falling back to a client built from the
+// configuration when none has been set.
+func (c *ProviderConfig) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return newHTTPClient(c)
+}
+
+// Validate checks the configuration as a whole and reports every problem it
+// finds, so users can fix all of them in a single pass.
+func (c *ProviderConfig) Validate() diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if c.Region != "" && c.BaseURL != "" {
+		diags = append(diags, configError("region", "Only one of region and base_url can be set"))
+	} else if _, ok := regionBaseURLs[c.Region]; c.Region != "" && !ok {
+		diags = append(diags, configError("region", fmt.Sprintf("%q must be one of %s", c.Region, strings.Join(regionNames(), ", "))))
+	}
+
+	if c.BaseURL != "" && !isHTTPURL(c.BaseURL) {
+		diags = append(diags, configError("base_url", fmt.Sprintf("%q is not a valid http(s) URL", c.BaseURL)))
+	}
+
+	if !apiVersionPattern.MatchString(c.APIVersion) {
+		diags = append(diags, configError("api_version", fmt.Sprintf("%q is not an API version such as %q", c.APIVersion, defaultAPIVersion)))
+	}
+
+	if c.OnSuccessWebhook != "" && !isHTTPURL(c.OnSuccessWebhook) {
+		diags = append(diags, configError("on_success_webhook", fmt.Sprintf("%q is not a valid http(s) URL", c.OnSuccessWebhook)))
+	}
+
+	if c.OnFailureWebhook != "" && !isHTTPURL(c.OnFailureWebhook) {
+		diags = append(diags, configError("on_failure_webhook", fmt.Sprintf("%q is not a valid http(s) URL", c.OnFailureWebhook)))
+	}
+
+	if c.Token() == "" && c.Username == "" && !c.Offline {
+		diags = append(diags, configError("token", "A CDO API token, or a username and password, must be set, either in the provider block or via CDO_TOKEN, CDO_USERNAME and CDO_PASSWORD"))
+	}
+
+	if c.Username != "" && c.Password == "" {
+		diags = append(diags, configError("password", "Must be set together with username"))
+	}
+
+	if c.LogFormat != logFormatText && c.LogFormat != logFormatJSON {
+		diags = append(diags, configError("log_format", fmt.Sprintf("%q must be one of %q or %q", c.LogFormat, logFormatText, logFormatJSON)))
+	}
+
+	if c.LogBodyMaxBytes < 0 {
+		diags = append(diags, configError("log_body_max_bytes", "Must be zero or greater"))
+	}
+
+	if c.ReadTimeout <= 0 {
+		diags = append(diags, configError("read_timeout_seconds", "Must be greater than zero"))
+	}
+	if c.WriteTimeout <= 0 {
+		diags = append(diags, configError("write_timeout_seconds", "Must be greater than zero"))
+	}
+	if c.DeleteTimeout <= 0 {
+		diags = append(diags, configError("delete_timeout_seconds", "Must be greater than zero"))
+	}
+
+	if c.PollInterval <= 0 {
+		diags = append(diags, configError("poll_interval_seconds", "Must be greater than zero"))
+	} else if c.PollTimeout < 0 {
+		diags = append(diags, configError("poll_timeout_seconds", "Must not be negative"))
+	} else if c.PollTimeout > 0 && c.PollTimeout <= c.PollInterval {
+		diags = append(diags, configError("poll_timeout_seconds", "Must be greater than poll_interval_seconds"))
+	}
+	if c.PollMaxInterval < 0 {
+		diags = append(diags, configError("poll_max_interval_seconds", "Must not be negative"))
+	} else if c.PollMaxInterval > 0 && c.PollMaxInterval < c.PollInterval {
+		diags = append(diags, configError("poll_max_interval_seconds", "Must not be less than poll_interval_seconds"))
+	}
+
+	if c.PinnedCertSHA256 != "" {
+		if b, err := hex.DecodeString(normalizeFingerprint(c.PinnedCertSHA256)); err != nil || len(b) != sha256.Size {
+			diags = append(diags, configError("pinned_cert_sha256", "Must be a hex encoded SHA-256 fingerprint"))
+		}
+	}
+
+	if c.CACertificate != "" {
+		if _, err := loadCACertificate(c.CACertificate); err != nil {
+			diags = append(diags, configError("ca_certificate", err.Error()))
+		}
+	}
+
+	if c.InsecureSkipVerify {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Warning,
+			Summary:       "TLS certificate verification is disabled",
+			Detail:        "insecure_skip_verify is set, so the identity of the CDO endpoint isn't checked and the API token can be intercepted. Use ca_certificate for endpoints with a private CA instead.",
+			AttributePath: cty.GetAttrPath("insecure_skip_verify"),
+		})
+	}
+
+	if c.ProxyURL != "" {
+		if u, err := url.Parse(c.ProxyURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			diags = append(diags, configError("proxy_url", fmt.Sprintf("%q is not a valid http(s) or socks5 URL", c.ProxyURL)))
+		}
+	}
+
+	if c.RequestsPerSecond < 0 {
+		diags = append(diags, configError("requests_per_second", "Must be zero or greater"))
+	}
+
+	if c.MaxConcurrentTransactions < 0 {
+		diags = append(diags, configError("max_concurrent_transactions", "Must be zero or greater"))
+	}
+
+	if c.MaxRetries < 0 {
+		diags = append(diags, configError("max_retries", "Must be zero or greater"))
+	}
+
+	if c.RetryWait <= 0 {
+		diags = append(diags, configError("retry_wait_seconds", "Must be greater than zero"))
+	}
+
+	for _, code := range c.RetryStatusCodes {
+		if code < 100 || code > 599 {
+			diags = append(diags, configError("retry_status_codes", fmt.Sprintf("%d is not a valid HTTP status code", code)))
+		}
+	}
+
+	return diags
+}
+
+// Token returns the current API token.
+func (c *ProviderConfig) Token() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+	return c.token
+}
+
+// SetToken replaces the API token used by subsequent requests.
+func (c *ProviderConfig) SetToken(token string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	c.token = token
+}
+
+// refreshToken replaces rejected, the token a request failed with, with one
+// from the TokenSource. When another request has already replaced it, the
+// newer token is kept and the source isn't asked again.
+func (c *ProviderConfig) refreshToken(ctx context.Context, rejected string) error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.token != rejected {
+		return nil
+	}
+	if c.TokenSource == nil {
+		return errTokenNotRefreshable
+	}
+
+	token, err := c.TokenSource.RefreshToken(ctx)
+	if err != nil {
+		return err
+	}
+	c.token = token
+	return nil
+}
+
+// TokenSource supplies replacement API tokens.
+type TokenSource interface {
+	// RefreshToken returns a new token after the API rejected the current
+	// one.
+	RefreshToken(ctx context.Context) (string, error)
+}
+
+var errTokenNotRefreshable = errors.New("the token can't be refreshed")
+
+// staticTokenSource is the TokenSource of a token configured once, which
+// has no way of getting a new one.
+type staticTokenSource struct{}
+
+func (staticTokenSource) RefreshToken(context.Context) (string, error) {
+	return "", errTokenNotRefreshable
+}
+
+// CheckWritable returns an error when the provider is in read_only or offline
+// mode, and must be called before any operation that modifies CDO.
+func (c *ProviderConfig) CheckWritable() error {
+	if c.Offline {
+		return errOffline
+	}
+	if c.ReadOnly {
+		return fmt.Errorf("The provider is configured with read_only = true and will not modify CDO")
+	}
+	return nil
+}
+
+// loadCACertificate builds a certificate pool from value, which is either PEM
+// data or the path of a PEM file.
+func loadCACertificate(value string) (*x509.CertPool, error) {
+	pem := []byte(value)
+	if !strings.Contains(value, "-----BEGIN") {
+		var err error
+		if pem, err = os.ReadFile(value); err != nil {
+			return nil, fmt.Errorf("Error reading CA certificate: %s", err)
+		}
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("No PEM encoded certificate found")
+	}
+	return pool, nil
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+func configError(attribute, detail string) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity:      diag.Error,
+		Summary:       fmt.Sprintf("Invalid provider argument %q", attribute),
+		Detail:        detail,
+		AttributePath: cty.GetAttrPath(attribute),
+	}
+}
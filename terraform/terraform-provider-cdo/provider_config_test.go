@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentTokenRefresh is meant to be run with -race: many requests get
+// their token rejected at once while others read it, and the token must be
+// refreshed only once.
+func TestConcurrentTokenRefresh(t *testing.T) {
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	source := &countingTokenSource{token: "fresh"}
+	config.TokenSource = source
+
+	const requests = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	done := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					if token := config.Token(); token != "token" && token != "fresh" {
+						t.Errorf("Token() = %q, want the old or the refreshed token", token)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	var requestsWG sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		requestsWG.Add(1)
+		go func() {
+			defer requestsWG.Done()
+			_, err := makeRequest(context.Background(), config, "GET", config.apiURL("/inventory/devices"), nil)
+			errs <- err
+		}()
+	}
+	requestsWG.Wait()
+	close(done)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("makeRequest() error = %s", err)
+		}
+	}
+	if source.calls != 1 {
+		t.Errorf("token refreshed %d times, want 1", source.calls)
+	}
+	if got := config.Token(); got != "fresh" {
+		t.Errorf("Token() = %q, want the refreshed token", got)
+	}
+}
+
+func TestRefreshTokenKeepsNewerToken(t *testing.T) {
+	config := &ProviderConfig{}
+	config.SetToken("newer")
+	source := &countingTokenSource{token: "fresh"}
+	config.TokenSource = source
+
+	if err := config.refreshToken(context.Background(), "rejected"); err != nil {
+		t.Fatalf("refreshToken() error = %s", err)
+	}
+	if got := config.Token(); got != "newer" {
+		t.Errorf("Token() = %q, want the token another request already set", got)
+	}
+	if source.calls != 0 {
+		t.Errorf("token refreshed %d times, want 0", source.calls)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// testAccNamePrefix starts the name of everything acceptance tests create, so
+// that sweepers can tell leaked test objects apart from real ones.
+const testAccNamePrefix = "tf-acc-"
+
+// testAccProviderFactories runs the provider in process for acceptance
+// tests.
+var testAccProviderFactories = map[string]func() (*schema.Provider, error){
+	"cdo": func() (*schema.Provider, error) { return Provider(), nil },
+}
+
+// TestMain runs the sweepers instead of the tests when go test is given
+// -sweep, e.g. go test . -v -sweep=all.
+func TestMain(m *testing.M) {
+	resource.TestMain(m)
+}
+
+func TestProvider(t *testing.T) {
+	if err := Provider().InternalValidate(); err != nil {
+		t.Fatalf("InternalValidate() error = %s", err)
+	}
+}
+
+func TestProviderConfigureMaxConcurrentOperations(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  map[string]interface{}
+		want int
+	}{
+		{name: "max_concurrent_operations", raw: map[string]interface{}{"max_concurrent_operations": 2}, want: 2},
+		{name: "max_concurrent_transactions", raw: map[string]interface{}{"max_concurrent_transactions": 3}, want: 3},
+		{name: "unset", raw: map[string]interface{}{}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.raw["offline"] = true
+			provider := Provider()
+			if diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(tt.raw)); diags.HasError() {
+				t.Fatalf("Configure() error = %v", diags)
+			}
+			if got := provider.Meta().(*ProviderConfig).MaxConcurrentTransactions; got != tt.want {
+				t.Errorf("MaxConcurrentTransactions = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// testAccPreCheck fails acceptance tests early when the CDO credentials they
+// need aren't set. Acceptance tests only run with TF_ACC set.
+func testAccPreCheck(t *testing.T) {
+	t.Helper()
+
+	if os.Getenv("CDO_TOKEN") == "" && (os.Getenv("CDO_USERNAME") == "" || os.Getenv("CDO_PASSWORD") == "") {
+		t.Fatal("CDO_TOKEN, or CDO_USERNAME and CDO_PASSWORD, must be set for acceptance tests")
+	}
+}
+
+// testAccPreCheckAccessPolicy checks CDO_TEST_ACCESS_POLICY_UUID, the access
+// policy acceptance tests onboard devices with.
+func testAccPreCheckAccessPolicy(t *testing.T) {
+	t.Helper()
+
+	if os.Getenv("CDO_TEST_ACCESS_POLICY_UUID") == "" {
+		t.Fatal("CDO_TEST_ACCESS_POLICY_UUID must be set for acceptance tests that onboard devices")
+	}
+}
+
+// sharedConfig configures the provider from the CDO_* environment variables,
+// for sweepers, which run outside of Terraform.
+func sharedConfig() (*ProviderConfig, error) {
+	provider := Provider()
+	if diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{})); diags.HasError() {
+		return nil, fmt.Errorf("Error configuring the provider: %v", diags)
+	}
+	return provider.Meta().(*ProviderConfig), nil
+}
+
+func TestAccProvider_configure(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `data "cdo_users" "all" {}`,
+				Check:  resource.TestCheckResourceAttrSet("data.cdo_users.all", "id"),
+			},
+		},
+	})
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+type apiTokenResponse struct {
+	APIToken string `json:"apiToken"`
+}
+
+// resourceAPIToken manages an API-only user together with its API token. The
+// token is generated on create, generated again whenever rotation_triggers
+// change, and revoked along with the user on destroy.
+func resourceAPIToken() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceAPITokenCreate,
+		ReadContext:   resourceAPITokenRead,
+		UpdateContext: resourceAPITokenUpdate,
+		DeleteContext: resourceAPITokenDelete,
+
+		CustomizeDiff: func(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+			if d.Id() != "" && d.HasChange("rotation_triggers") {
+				return d.SetNewComputed("token")
+			}
+			return nil
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the API-only user the token belongs to.",
+			},
+			"role": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(userRoles, false),
+				Description:  fmt.Sprintf("Role of the API-only user, one of %s.", strings.Join(userRoles, ", ")),
+			},
+			"rotation_triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values that generate a new token when changed, e.g. a rotation date.",
+			},
+			"token": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The API token. Generating a new one revokes the previous token.",
+			},
+		},
+	}
+}
+
+func resourceAPITokenCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	user, err := createUser(ctx, config, d.Get("name").(string), d.Get("role").(string), true)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Without a token the resource is of no use, and a token can't be
+	// generated again without a change to rotation_triggers, so the user is
+	// deleted rather than kept in state.
+	token, err := generateAPIToken(ctx, config, string(user.Uid))
+	if err != nil {
+		diags := diag.FromErr(err)
+		return append(diags, cleanupFailedAPIToken(ctx, d, config, string(user.Uid))...)
+	}
+	d.SetId(string(user.Uid))
+	d.Set("token", token)
+
+	return resourceAPITokenRead(ctx, d, m)
+}
+
+// cleanupFailedAPIToken deletes an API-only user whose token couldn't be
+// generated. If that fails too, the user is kept in state as tainted, to be
+// replaced by the next apply.
+func cleanupFailedAPIToken(ctx context.Context, d *schema.ResourceData, config *ProviderConfig, uid string) diag.Diagnostics {
+	if err := deleteUser(ctx, config, uid, transactionWait(config, d, schema.TimeoutCreate)); err != nil {
+		d.SetId(uid)
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "API-only user was not cleaned up",
+			Detail:   fmt.Sprintf("Deleting API-only user %s after generating its token failed returned an error, it is kept in state as tainted: %s", uid, err),
+		}}
+	}
+	return nil
+}
+
+func resourceAPITokenRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	user, err := getUser(ctx, config, d.Id())
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] API-only user %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading user: %s", err)
+	}
+
+	// The token can't be read back, it is only known from when it was
+	// generated.
+	d.Set("name", user.Name)
+	d.Set("role", strings.TrimPrefix(user.Role, rolePrefix))
+	return nil
+}
+
+func resourceAPITokenUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if d.HasChange("role") {
+		if err := updateUserRole(ctx, config, d.Id(), d.Get("role").(string)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChange("rotation_triggers") {
+		token, err := generateAPIToken(ctx, config, d.Id())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		d.Set("token", token)
+	}
+
+	return resourceAPITokenRead(ctx, d, m)
+}
+
+func resourceAPITokenDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Revoke the token first, so it stops working even if deleting the user
+	// fails.
+	_, err := makeRequest(
+		ctx,
+		config,
+		"DELETE",
+		config.apiURL(fmt.Sprintf("/users/%s/api-token", d.Id())),
+		nil,
+	)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return diag.Errorf("Error revoking API token: %s", err)
+	}
+
+	if err := deleteUser(ctx, config, d.Id(), transactionWait(config, d, schema.TimeoutDelete)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// generateAPIToken generates a new API token for the user, replacing any
+// token it already had.
+func generateAPIToken(ctx context.Context, config *ProviderConfig, uid string) (string, error) {
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		config.apiURL(fmt.Sprintf("/users/%s/api-token", uid)),
+		nil,
+	)
+	if err != nil {
+		return "", fmt.Errorf("Error generating API token: %s", err)
+	}
+
+	var token apiTokenResponse
+	if err := decodeJSON(resp, &token); err != nil {
+		return "", fmt.Errorf("Error parsing response: %s", err)
+	}
+	return token.APIToken, nil
+}
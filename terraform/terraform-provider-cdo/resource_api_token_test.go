@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAPITokenCreate(t *testing.T) {
+	mock, config := newMockCDO(t)
+	const userPath = "/users/00000000-0000-0000-0000-000000000001"
+	mock.Handle("POST", userPath+"/api-token", func(*mockCDO, map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, apiTokenResponse{APIToken: "secret"}
+	})
+
+	state := mockApply(t, resourceAPIToken(), nil, map[string]interface{}{"name": "pipeline", "role": "ADMIN"}, config)
+
+	if state.ID != "00000000-0000-0000-0000-000000000001" {
+		t.Errorf("ID = %q, want the user's UID", state.ID)
+	}
+	if got := state.Attributes["token"]; got != "secret" {
+		t.Errorf("token = %q, want the generated token", got)
+	}
+}
+
+func TestAPITokenCreateCleansUpUserWithoutToken(t *testing.T) {
+	tests := []struct {
+		name         string
+		deleteStatus int
+		wantID       string
+	}{
+		{name: "user deleted", deleteStatus: http.StatusNoContent},
+		{name: "user not deleted", deleteStatus: http.StatusForbidden, wantID: "00000000-0000-0000-0000-000000000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, config := newMockCDO(t)
+			const userPath = "/users/00000000-0000-0000-0000-000000000001"
+			mock.Handle("POST", userPath+"/api-token", func(*mockCDO, map[string]interface{}) (int, interface{}) {
+				return http.StatusInternalServerError, nil
+			})
+			mock.Handle("DELETE", userPath, func(*mockCDO, map[string]interface{}) (int, interface{}) {
+				return tt.deleteStatus, nil
+			})
+
+			r := resourceAPIToken()
+			raw := map[string]interface{}{"name": "pipeline", "role": "ADMIN"}
+			diff, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(raw), config)
+			if err != nil {
+				t.Fatalf("Diff() error = %s", err)
+			}
+			state, diags := r.Apply(context.Background(), nil, diff, config)
+			if !diags.HasError() {
+				t.Fatal("Apply() succeeded, want an error")
+			}
+
+			var id string
+			if state != nil {
+				id = state.ID
+			}
+			if id != tt.wantID {
+				t.Errorf("ID = %q, want %q", id, tt.wantID)
+			}
+			if requests := mock.Requests(); requests[len(requests)-1] != "DELETE "+userPath {
+				t.Errorf("requests = %v, want the user deleted last", requests)
+			}
+			if state != nil && state.Attributes["token"] != "" {
+				t.Errorf("token = %q, want none", state.Attributes["token"])
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+var asaDeviceType = DeviceType{
+	Name:         "ASA device",
+	OnboardPath:  "/inventory/devices/asas",
+	DeletePath:   "/inventory/devices/asas/%s",
+	DeleteMethod: "DELETE",
+}
+
+var asaDevice = sdcDeviceKind{
+	DeviceType:                   asaDeviceType,
+	Resource:                     "cdo_asa_device",
+	Path:                         "/inventory/devices/asas/%s",
+	DefaultPort:                  443,
+	HostDescription:              "Hostname or IP address of the ASA's management interface.",
+	IgnoreCertificateDescription: "Onboard the ASA even when its certificate can't be verified.",
+}
+
+// resourceASADevice onboards an ASA through a Secure Device Connector, which
+// logs in to the ASA with the given credentials.
+func resourceASADevice() *schema.Resource {
+	return resourceSDCDevice(asaDevice)
+}
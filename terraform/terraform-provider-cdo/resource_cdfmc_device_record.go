@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// resourceCDFMCDeviceRecord manages the settings cdFMC keeps for an FTD once
+// it has been onboarded, such as its NAT ID and license capabilities. The
+// device record belongs to the device, so deleting the resource only stops
+// managing the settings.
+func resourceCDFMCDeviceRecord() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceCDFMCDeviceRecordCreate,
+		ReadContext:   resourceCDFMCDeviceRecordRead,
+		UpdateContext: resourceCDFMCDeviceRecordUpdate,
+		DeleteContext: resourceCDFMCDeviceRecordDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceCDFMCDeviceRecordImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"device_uid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "CDO UID of the cdFMC managed FTD, e.g. the id of a cdo_ftd_device.",
+			},
+			"device_record_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the device record in cdFMC.",
+			},
+			"nat_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "NAT ID used to register the device when it is behind NAT. cdFMC doesn't return it, so changes made outside of Terraform aren't detected.",
+			},
+			"license_caps": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(ftdLicenses, false),
+				},
+				Description: "License capabilities reserved for the device in cdFMC.",
+			},
+			"prohibit_packet_transfer": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Prevent the device from sending packet data to cdFMC with events.",
+			},
+			"deploy": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Deploy the pending changes to the device after the settings have been updated.",
+			},
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Update: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+func resourceCDFMCDeviceRecordCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	device, err := getFTDDevice(ctx, config, d.Get("device_uid").(string))
+	if err != nil {
+		return diag.Errorf("Error reading FTD device: %s", err)
+	}
+	if device.UidOnFmc == "" {
+		return diag.Errorf("FTD device %s is not registered with cdFMC yet", d.Get("device_uid").(string))
+	}
+
+	d.SetId(d.Get("device_uid").(string))
+	d.Set("device_record_id", device.UidOnFmc)
+
+	if err := applyDeviceRecord(ctx, d, config, schema.TimeoutCreate); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceCDFMCDeviceRecordRead(ctx, d, m)
+}
+
+func resourceCDFMCDeviceRecordRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	record, err := getDeviceRecord(ctx, config, d.Get("device_record_id").(string))
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] cdFMC device record of %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading device record: %s", err)
+	}
+
+	d.Set("license_caps", record.LicenseCaps)
+	d.Set("prohibit_packet_transfer", record.ProhibitPacketTransfer)
+	return nil
+}
+
+func resourceCDFMCDeviceRecordUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if !d.HasChanges("nat_id", "license_caps", "prohibit_packet_transfer") {
+		return nil
+	}
+
+	if err := applyDeviceRecord(ctx, d, config, schema.TimeoutUpdate); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceCDFMCDeviceRecordRead(ctx, d, m)
+}
+
+func resourceCDFMCDeviceRecordDelete(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// The record is removed together with the device, only stop managing it.
+	d.SetId("")
+	return nil
+}
+
+func resourceCDFMCDeviceRecordImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	config := m.(*ProviderConfig)
+
+	device, err := getFTDDevice(ctx, config, d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("Error importing device record: %s", err)
+	}
+	if device.UidOnFmc == "" {
+		return nil, fmt.Errorf("Error importing device record: FTD device %s is not registered with cdFMC", d.Id())
+	}
+
+	d.Set("device_uid", d.Id())
+	d.Set("device_record_id", device.UidOnFmc)
+	d.Set("deploy", true)
+	return []*schema.ResourceData{d}, nil
+}
+
+// applyDeviceRecord writes the configured settings to the device record and,
+// unless deploy is false, deploys them to the device.
+func applyDeviceRecord(ctx context.Context, d *schema.ResourceData, config *ProviderConfig, timeoutKey string) error {
+	record, err := getDeviceRecord(ctx, config, d.Get("device_record_id").(string))
+	if err != nil {
+		return fmt.Errorf("Error reading device record: %s", err)
+	}
+
+	record.NatID = d.Get("nat_id").(string)
+	record.ProhibitPacketTransfer = d.Get("prohibit_packet_transfer").(bool)
+	if v, ok := d.GetOk("license_caps"); ok {
+		var caps []string
+		for _, c := range v.(*schema.Set).List() {
+			caps = append(caps, c.(string))
+		}
+		sort.Strings(caps)
+		record.LicenseCaps = caps
+	}
+
+	if err := updateDeviceRecord(ctx, config, record); err != nil {
+		return err
+	}
+
+	if d.Get("deploy").(bool) {
+		return deployDevice(ctx, config, d.Get("device_uid").(string), transactionWait(config, d, timeoutKey))
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const (
+	cloudProviderAWS   = "AWS"
+	cloudProviderAzure = "AZURE"
+	cloudProviderGCP   = "GCP"
+)
+
+var cloudProviders = []string{cloudProviderAWS, cloudProviderAzure, cloudProviderGCP}
+
+// CloudAccount is a public cloud account CDO has been given access to, e.g. to
+// discover and protect its networks with Multicloud Defense.
+type CloudAccount struct {
+	Uid                 flexString `json:"uid"`
+	Name                string     `json:"name"`
+	CloudProvider       string     `json:"cloudProvider"`
+	AccountId           string     `json:"accountId"`
+	TenantId            string     `json:"tenantId"`
+	CredentialReference string     `json:"credentialReference"`
+	Status              string     `json:"status"`
+}
+
+// resourceCloudAccount onboards an AWS account, Azure subscription or GCP
+// project. CDO accesses it through a role or identity created in the cloud
+// beforehand, e.g. with the cloud's own Terraform provider, which is only
+// referenced here.
+func resourceCloudAccount() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceCloudAccountCreate,
+		ReadContext:   resourceCloudAccountRead,
+		DeleteContext: resourceCloudAccountDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		CustomizeDiff: resourceCloudAccountCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringLenBetween(1, 128),
+			},
+			"cloud_provider": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(cloudProviders, false),
+				Description:  fmt.Sprintf("Cloud the account belongs to, one of %s.", strings.Join(cloudProviders, ", ")),
+			},
+			"account_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "AWS account ID, Azure subscription ID or GCP project ID.",
+			},
+			"azure_tenant_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsUUID,
+				Description:  "Microsoft Entra tenant of the subscription, required for Azure.",
+			},
+			"credential_reference": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Identity CDO assumes in the account: the ARN of an IAM role for AWS, the client ID of a service principal for Azure, or the email of a service account for GCP.",
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(15 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+	}
+}
+
+func resourceCloudAccountCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() != "" || !d.NewValueKnown("cloud_provider") || !d.NewValueKnown("azure_tenant_id") {
+		return nil
+	}
+
+	isAzure := d.Get("cloud_provider").(string) == cloudProviderAzure
+	hasTenant := d.Get("azure_tenant_id").(string) != ""
+	switch {
+	case isAzure && !hasTenant:
+		return fmt.Errorf("azure_tenant_id is required when cloud_provider is %q", cloudProviderAzure)
+	case !isAzure && hasTenant:
+		return fmt.Errorf("azure_tenant_id can only be set when cloud_provider is %q", cloudProviderAzure)
+	}
+	return nil
+}
+
+func resourceCloudAccountCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	release, err := config.acquireTransactionSlot(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
+
+	payload := map[string]interface{}{
+		"name":                d.Get("name").(string),
+		"cloudProvider":       d.Get("cloud_provider").(string),
+		"accountId":           d.Get("account_id").(string),
+		"credentialReference": d.Get("credential_reference").(string),
+	}
+	if v, ok := d.GetOk("azure_tenant_id"); ok {
+		payload["tenantId"] = v.(string)
+	}
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		config.apiURL("/cloud-accounts"),
+		payload,
+	)
+	if err != nil {
+		return diag.Errorf("Error creating cloud account: %s", err)
+	}
+
+	var transaction TransactionResponse
+	if err := decodeJSON(resp, &transaction); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+	if transaction.EntityUid != "" {
+		d.SetId(string(transaction.EntityUid))
+	}
+
+	if err := waitFor(ctx, config, transaction.TransactionPollingURL, transactionWait(config, d, schema.TimeoutCreate)); err != nil {
+		return diag.FromErr(err)
+	}
+	if d.Id() == "" {
+		return diag.Errorf("Error creating cloud account: the response didn't include the account UID")
+	}
+
+	return resourceCloudAccountRead(ctx, d, m)
+}
+
+func resourceCloudAccountRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/cloud-accounts/%s", d.Id())),
+		nil,
+	)
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] Cloud account %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading cloud account: %s", err)
+	}
+
+	var account CloudAccount
+	if err := decodeJSON(resp, &account); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	d.Set("name", account.Name)
+	d.Set("cloud_provider", account.CloudProvider)
+	d.Set("account_id", account.AccountId)
+	d.Set("azure_tenant_id", account.TenantId)
+	d.Set("credential_reference", account.CredentialReference)
+	d.Set("status", account.Status)
+	return nil
+}
+
+func resourceCloudAccountDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	release, err := config.acquireTransactionSlot(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
+
+	resp, err := makeAPIRequest(
+		ctx,
+		config,
+		"DELETE",
+		config.apiURL(fmt.Sprintf("/cloud-accounts/%s", d.Id())),
+		nil,
+	)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return diag.Errorf("Error deleting cloud account: %s", err)
+	}
+
+	if err == nil {
+		transaction, err := deleteTransaction(resp)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if transaction != nil {
+			if err := waitFor(ctx, config, transaction.TransactionPollingURL, deleteWait(d)); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const deviceGroupPageSize = 200
+
+// DeviceGroup is a named set of inventory devices that bulk actions can be
+// run against.
+type DeviceGroup struct {
+	Uid        flexString `json:"uid"`
+	Name       string     `json:"name"`
+	DeviceUids []string   `json:"deviceUids"`
+}
+
+type deviceGroupPage struct {
+	Count flexInt       `json:"count"`
+	Items []DeviceGroup `json:"items"`
+}
+
+// resourceDeviceGroup manages a CDO device group and its members. Members are
+// added and removed in place, without recreating the group.
+func resourceDeviceGroup() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDeviceGroupCreate,
+		ReadContext:   resourceDeviceGroupRead,
+		UpdateContext: resourceDeviceGroupUpdate,
+		DeleteContext: resourceDeviceGroupDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringLenBetween(1, 128),
+			},
+			"device_uids": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.IsUUID,
+				},
+				Description: "UIDs of the devices in the group.",
+			},
+		},
+	}
+}
+
+func resourceDeviceGroupCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		config.apiURL("/inventory/groups"),
+		map[string]interface{}{
+			"name":       d.Get("name").(string),
+			"deviceUids": stringSet(d.Get("device_uids").(*schema.Set)),
+		},
+	)
+	if err != nil {
+		return diag.Errorf("Error creating device group: %s", err)
+	}
+
+	var group DeviceGroup
+	if err := decodeJSON(resp, &group); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	d.SetId(string(group.Uid))
+	return resourceDeviceGroupRead(ctx, d, m)
+}
+
+func resourceDeviceGroupRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	group, err := getDeviceGroup(ctx, config, d.Id())
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] Device group %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading device group: %s", err)
+	}
+
+	d.Set("name", group.Name)
+	d.Set("device_uids", group.DeviceUids)
+	return nil
+}
+
+func resourceDeviceGroupUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if d.HasChange("name") {
+		_, err := makeRequest(
+			ctx,
+			config,
+			"PATCH",
+			config.apiURL(fmt.Sprintf("/inventory/groups/%s", d.Id())),
+			map[string]interface{}{
+				"name": d.Get("name").(string),
+			},
+		)
+		if err != nil {
+			return diag.Errorf("Error renaming device group: %s", err)
+		}
+	}
+
+	if d.HasChange("device_uids") {
+		o, n := d.GetChange("device_uids")
+		before, after := o.(*schema.Set), n.(*schema.Set)
+
+		_, err := makeRequest(
+			ctx,
+			config,
+			"PATCH",
+			config.apiURL(fmt.Sprintf("/inventory/groups/%s/devices", d.Id())),
+			map[string]interface{}{
+				"add":    stringSet(after.Difference(before)),
+				"remove": stringSet(before.Difference(after)),
+			},
+		)
+		if err != nil {
+			return diag.Errorf("Error updating device group members: %s", err)
+		}
+	}
+
+	return resourceDeviceGroupRead(ctx, d, m)
+}
+
+func resourceDeviceGroupDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	_, err := makeRequest(
+		ctx,
+		config,
+		"DELETE",
+		config.apiURL(fmt.Sprintf("/inventory/groups/%s", d.Id())),
+		nil,
+	)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return diag.Errorf("Error deleting device group: %s", err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func getDeviceGroup(ctx context.Context, config *ProviderConfig, uid string) (*DeviceGroup, error) {
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/inventory/groups/%s", uid)),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var group DeviceGroup
+	if err := decodeJSON(resp, &group); err != nil {
+		return nil, fmt.Errorf("Error parsing response: %s", err)
+	}
+	return &group, nil
+}
+
+// findDeviceGroupByName returns the only device group with exactly the given
+// name, following the pagination.
+func findDeviceGroupByName(ctx context.Context, config *ProviderConfig, name string) (*DeviceGroup, error) {
+	var matches []DeviceGroup
+
+	for offset, fetched := 0, 0; ; offset += deviceGroupPageSize {
+		params := url.Values{}
+		params.Set("limit", fmt.Sprint(deviceGroupPageSize))
+		params.Set("offset", fmt.Sprint(offset))
+		params.Set("q", inventoryQuery("name", name))
+
+		resp, err := makeRequest(
+			ctx,
+			config,
+			"GET",
+			config.apiURL(fmt.Sprintf("/inventory/groups?%s", params.Encode())),
+			nil,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("Error listing device groups: %s", err)
+		}
+
+		var page deviceGroupPage
+		if err := decodeJSON(resp, &page); err != nil {
+			return nil, fmt.Errorf("Error parsing response: %s", err)
+		}
+
+		for _, group := range page.Items {
+			if group.Name == name {
+				matches = append(matches, group)
+			}
+		}
+		fetched += len(page.Items)
+		if len(page.Items) == 0 || fetched >= int(page.Count) {
+			break
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no device group named %q found", name)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("%d device groups named %q found", len(matches), name)
+	}
+}
+
+// stringSet returns the elements of a set of strings, never nil so that it
+// encodes as an empty JSON array.
+func stringSet(s *schema.Set) []string {
+	values := make([]string, 0, s.Len())
+	for _, v := range s.List() {
+		values = append(values, v.(string))
+	}
+	return values
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const (
+	testDeviceUID1 = "11111111-1111-1111-1111-111111111111"
+	testDeviceUID2 = "22222222-2222-2222-2222-222222222222"
+)
+
+func TestDeviceGroupLifecycle(t *testing.T) {
+	mock, config := newMockCDO(t)
+	r := resourceDeviceGroup()
+
+	state := mockApply(t, r, nil, map[string]interface{}{
+		"name":        "branches",
+		"device_uids": []interface{}{testDeviceUID1},
+	}, config)
+	path := "/inventory/groups/" + state.ID
+	if got := mock.Object(path)["name"]; got != "branches" {
+		t.Fatalf("group name = %v, want branches", got)
+	}
+
+	mock.Handle("PATCH", path+"/devices", func(m *mockCDO, body map[string]interface{}) (int, interface{}) {
+		members := map[string]bool{}
+		for _, uid := range m.Object(path)["deviceUids"].([]interface{}) {
+			members[uid.(string)] = true
+		}
+		for _, uid := range body["add"].([]interface{}) {
+			members[uid.(string)] = true
+		}
+		for _, uid := range body["remove"].([]interface{}) {
+			delete(members, uid.(string))
+		}
+		uids := []interface{}{}
+		for uid := range members {
+			uids = append(uids, uid)
+		}
+		m.Object(path)["deviceUids"] = uids
+		return http.StatusOK, m.Object(path)
+	})
+
+	state = mockApply(t, r, state, map[string]interface{}{
+		"name":        "all-branches",
+		"device_uids": []interface{}{testDeviceUID2},
+	}, config)
+	if got := state.Attributes["name"]; got != "all-branches" {
+		t.Errorf("name = %q, want all-branches", got)
+	}
+	if got := state.Attributes["device_uids.#"]; got != "1" {
+		t.Errorf("device_uids.# = %q, want 1", got)
+	}
+	if got := mock.Object(path)["deviceUids"]; !reflect.DeepEqual(got, []interface{}{testDeviceUID2}) {
+		t.Errorf("group members = %v, want only %s", got, testDeviceUID2)
+	}
+
+	mockDestroy(t, r, state, config)
+	if mock.Object(path) != nil {
+		t.Errorf("group %s still exists after destroy", state.ID)
+	}
+
+	sent := map[string]bool{}
+	for _, request := range mock.Requests() {
+		sent[request] = true
+	}
+	for _, want := range []string{
+		"POST /inventory/groups",
+		"PATCH " + path,
+		"PATCH " + path + "/devices",
+		"DELETE " + path,
+	} {
+		if !sent[want] {
+			t.Errorf("no %s request sent, got %v", want, mock.Requests())
+		}
+	}
+}
+
+func TestDeviceGroupReadRemovesDeletedGroup(t *testing.T) {
+	_, config := newMockCDO(t)
+	r := resourceDeviceGroup()
+
+	d := r.TestResourceData()
+	d.SetId("00000000-0000-0000-0000-000000000042")
+	if diags := r.ReadContext(context.Background(), d, config); diags.HasError() {
+		t.Fatalf("Read() error = %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("Id() = %q, want the group removed from state", d.Id())
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// resourceDeviceSync asks CDO to re-read a device's configuration. A sync is
+// performed on create and again whenever device_uid or triggers change.
+func resourceDeviceSync() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDeviceSyncCreate,
+		ReadContext:   resourceDeviceSyncRead,
+		DeleteContext: resourceDeviceSyncDelete,
+
+		Schema: map[string]*schema.Schema{
+			"device_uid": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values that trigger a new sync when changed.",
+			},
+		},
+	}
+}
+
+func resourceDeviceSyncCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	release, err := config.acquireTransactionSlot(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		config.apiURL(fmt.Sprintf("/inventory/devices/%s/sync", d.Get("device_uid").(string))),
+		nil,
+	)
+	if err != nil {
+		return diag.Errorf("Error syncing device: %s", err)
+	}
+
+	var transaction TransactionResponse
+	if err := decodeJSON(resp, &transaction); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	if err := waitFor(ctx, config, transaction.TransactionPollingURL, transactionWait(config, d, schema.TimeoutCreate)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(id.UniqueId())
+	return nil
+}
+
+func resourceDeviceSyncRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// A sync is a one-off action, there is nothing to read back.
+	return nil
+}
+
+func resourceDeviceSyncDelete(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -1,203 +1,1072 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"log"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
-type TransactionResponse struct {
-	TransactionPollingURL string `json:"transactionPollingUrl"`
-	CDOTransactionStatus  string `json:"cdoTransactionStatus"`
-	EntityUid             string `json:"entityUid"`
+const (
+	managementModeCDFMC     = "cdfmc"
+	managementModeOnPremFMC = "on_prem_fmc"
+)
+
+const (
+	onboardingMethodZTP             = "ztp"
+	onboardingMethodRegistrationKey = "registration_key"
+)
+
+// serialImportPrefix marks an import ID as a serial number rather than a UID.
+const serialImportPrefix = "serial:"
+
+// connectivityStateOnline is the connectivity state of a device that has
+// completed onboarding and is reachable.
+const connectivityStateOnline = "ONLINE"
+
+// Claim states of a device onboarded with zero-touch provisioning, which can
+// take hours to call home after its serial number has been claimed.
+const (
+	claimStatePendingRegistration = "PENDING_REGISTRATION"
+	claimStateRegistered          = "REGISTERED"
+)
+
+// errRegistrationPending is returned by createFTDDevice when a ZTP device was
+// claimed but hasn't registered yet, and isn't waited for any longer.
+var errRegistrationPending = errors.New("FTD device has not registered yet")
+
+const (
+	deviceIndexTimeout  = 2 * time.Minute
+	deviceIndexInterval = 5 * time.Second
+)
+
+// validateAdminPassword enforces the FTD admin password policy: at least 8
+// characters with a lowercase and an uppercase letter, a digit and a special
+// character, and no character repeated more than twice in a row. The password
+// itself is never included in the error.
+func validateAdminPassword(v interface{}, k string) ([]string, []error) {
+	password := v.(string)
+
+	var missing []string
+	if len(password) < 8 {
+		missing = append(missing, "at least 8 characters")
+	}
+	if !strings.ContainsAny(password, "abcdefghijklmnopqrstuvwxyz") {
+		missing = append(missing, "a lowercase letter")
+	}
+	if !strings.ContainsAny(password, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+		missing = append(missing, "an uppercase letter")
+	}
+	if !strings.ContainsAny(password, "0123456789") {
+		missing = append(missing, "a digit")
+	}
+	if !strings.ContainsFunc(password, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) {
+		missing = append(missing, "a special character")
+	}
+
+	var errs []error
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("%s must contain %s", k, strings.Join(missing, ", ")))
+	}
+	for i := 2; i < len(password); i++ {
+		if password[i] == password[i-1] && password[i] == password[i-2] {
+			errs = append(errs, fmt.Errorf("%s must not repeat a character more than twice in a row", k))
+			break
+		}
+	}
+	return nil, errs
+}
+
+// ftdPerformanceTiers are the performance tiers a virtual FTD can be licensed
+// for, FTDv being the legacy variable tier.
+var ftdPerformanceTiers = []string{"FTDv", "FTDv5", "FTDv10", "FTDv20", "FTDv30", "FTDv50", "FTDv100"}
+
+// ftdLicenseReservations are the Smart Licensing reservations that let a
+// device without access to the licensing cloud use its licenses: specific
+// reservation of individual licenses or a permanent one for all of them.
+var ftdLicenseReservations = []string{"SPECIFIC", "PERMANENT"}
+
+type FTDDevice struct {
+	Uid               flexString `json:"uid"`
+	Name              string     `json:"name"`
+	Description       string     `json:"description"`
+	SerialNumber      string     `json:"serial"`
+	AccessPolicyUid   string     `json:"fmcAccessPolicyUid"`
+	ConnectivityState string     `json:"connectivityState"`
+	SoftwareVersion   string     `json:"softwareVersion"`
+	UidOnFmc          string     `json:"uidOnFmc"`
+	LarType           string     `json:"larType"`
+	Capabilities      []string   `json:"capabilities"`
+	Licenses          []License  `json:"licenses"`
+	Labels            Labels     `json:"labels"`
+}
+
+// Labels are the labels CDO groups and filters devices by.
+type Labels struct {
+	UngroupedLabels []string `json:"ungroupedLabels"`
+}
+
+// License is the entitlement status of one license requested for a device.
+type License struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
 }
 
 func resourceFTDDevice() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceFTDDeviceCreate,
-		Read:   resourceFTDDeviceRead,
-		Delete: resourceFTDDeviceDelete,
+		CreateContext: resourceFTDDeviceCreate,
+		ReadContext:   resourceFTDDeviceRead,
+		UpdateContext: resourceFTDDeviceUpdate,
+		DeleteContext: resourceFTDDeviceDelete,
+
+		CustomizeDiff: resourceFTDDeviceCustomizeDiff,
+
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceFTDDeviceV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceFTDDeviceStateUpgradeV0,
+			},
+		},
+
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceFTDDeviceImport,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"name": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateDeviceName,
 			},
 			"serial_number": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateSerialNumber,
+				Description:  "Serial number of the device, required for ZTP onboarding. Devices onboarded with a registration key report theirs once registered.",
+			},
+			"onboarding_method": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      onboardingMethodZTP,
+				ValidateFunc: validation.StringInSlice([]string{onboardingMethodZTP, onboardingMethodRegistrationKey}, false),
+				// Imported devices don't record how they were onboarded.
+				DiffSuppressFunc: suppressAfterCreate,
+				Description:      "Whether the device is onboarded by serial number with zero-touch provisioning (\"ztp\"), or by running the generated cli_command on the device (\"registration_key\").",
 			},
 			"access_policy_uuid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IsUUID,
+				Description:  "Access policy assigned to the device. Defaults to the provider's default_access_policy_uuid.",
+			},
+			"management_mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      managementModeCDFMC,
+				ValidateFunc: validation.StringInSlice([]string{managementModeCDFMC, managementModeOnPremFMC}, false),
+				Description:  "Whether the device is managed by the cloud-delivered FMC (\"cdfmc\") or by an on-prem FMC (\"on_prem_fmc\").",
+			},
+			"on_prem_fmc_uid": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "UID of the on-prem FMC the device is onboarded to. Required when management_mode is \"on_prem_fmc\".",
+			},
+			"description": {
 				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Optional: true,
+			},
+			"staging_access_policy_uuid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsUUID,
+				Description:  "Access policy the device is onboarded with. When set, the device is moved to access_policy_uuid by the next apply.",
+			},
+			"assigned_access_policy_uuid": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"access_policy_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the access policy the device is assigned to. Only read for devices managed by the cloud-delivered FMC.",
+			},
+			"access_policy_description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"access_policy_type": {
+				Type:     schema.TypeString,
+				Computed: true,
 			},
 			"admin_password": {
-				Type: 	schema.TypeString,
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				Sensitive:        true,
+				DiffSuppressFunc: suppressAfterCreate,
+				ValidateFunc:     validateAdminPassword,
+				Description:      "Admin password set on the device during onboarding. It is write-only: it is never read back nor kept in state, so later changes to it are ignored until admin_password_version changes.",
+			},
+			"admin_password_version": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Any value, changing it sets the device's admin password to the configured admin_password. Bump it together with admin_password to rotate the password without replacing the device.",
+			},
+			"smart_license_token": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				Sensitive:        true,
+				DiffSuppressFunc: suppressAfterCreate,
+				Description:      "Smart Licensing registration token the device registers with during zero-touch provisioning, selecting the virtual account its licenses come from. It is only sent on create and is not kept in state, so later changes to it are ignored.",
+			},
+			"license_reservation": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(ftdLicenseReservations, false),
+				Description:  fmt.Sprintf("License reservation the device uses instead of connecting to Smart Licensing, one of %s. Only supported with zero-touch provisioning.", strings.Join(ftdLicenseReservations, ", ")),
+			},
+			"poll_interval_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Seconds between transaction status checks while onboarding or updating this device, overriding the provider default.",
+			},
+			"poll_max_interval_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Upper bound in seconds the delay between transaction status checks backs off to for this device, overriding the provider default.",
+			},
+			"poll_max_attempts": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Number of transaction status checks while onboarding or updating this device before giving up, overriding the provider default.",
+			},
+			"bootstrap_data": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Bootstrap bundle returned by onboarding, to be applied on the device to complete registration.",
+			},
+			"registration_key": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Registration key returned by onboarding, to be entered on the device to complete registration.",
+			},
+			"cli_command": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The configure manager add command to run on the device to complete registration_key onboarding.",
+			},
+			"capabilities": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Features supported by the device model, e.g. whether snort3 is available.",
+			},
+			"connectivity_state": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Whether CDO can reach the device, e.g. ONLINE once registration has completed.",
+			},
+			"software_version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"cdfmc_device_uid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the device record in cdFMC, for use with the FMC API or provider.",
+			},
+			"lar_type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "How CDO connects to the device: CDG through the cloud connector, or SDC through a Secure Device Connector.",
+			},
+			"state": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Outcome of the onboarding transaction as last seen by the provider: PENDING, DONE, ERROR or TIMEOUT.",
+			},
+			"wait_for_registration": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Wait for a device onboarded with zero-touch provisioning to call home and register. When false, or when the wait times out, the create succeeds with a warning and claim_state reports the registration on a later refresh.",
+			},
+			"claim_state": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Whether a device onboarded with zero-touch provisioning has registered with CDO yet: PENDING_REGISTRATION or REGISTERED.",
+			},
+			"last_transaction_uid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "UID of the onboarding transaction, to look it up with the cdo_transaction data source.",
+			},
+			"last_transaction_error": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Error CDO reported for the last failed onboarding, deployment or update transaction of the device. Empty after a successful one.",
+			},
+			"allow_replacement": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Acknowledge that changes which replace the device may be applied when the provider sets prevent_device_replacement.",
+			},
+			"auto_cleanup_on_failure": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Delete the device from CDO when onboarding fails, instead of keeping the partially onboarded device in state as tainted.",
+			},
+			"labels": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Labels to group and filter the device by in CDO.",
+			},
+			"licenses": licensesSchema(ftdLicenses, ftdDeviceType.DefaultLicenses),
+			"license_status": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Entitlement status of each license requested for the device, keyed by license name.",
+			},
+			"initial_rules": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Access rules added to the device's access policy and deployed right after onboarding.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"action": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice([]string{ruleActionAllow, ruleActionBlock, ruleActionTrust, ruleActionMonitor}, false),
+						},
+						"source_networks": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"destination_networks": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"enabled": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+					},
+				},
+			},
+			"initial_rule_ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the access rules created from initial_rules. They are deleted together with the device.",
+			},
+			"initial_rules_access_policy_uuid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "UUID of the access policy the initial_rules were added to.",
+			},
+			"dns_servers": {
+				Type:     schema.TypeList,
 				Optional: true,
 				ForceNew: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.IsIPAddress,
+				},
+				Description: "DNS servers the device is bootstrapped with during onboarding.",
+			},
+			"performance_tier": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(ftdPerformanceTiers, false),
+				Description:  fmt.Sprintf("Performance tier of a virtual FTD, one of %s. Leave unset for hardware devices.", strings.Join(ftdPerformanceTiers, ", ")),
 			},
 		},
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
 			Delete: schema.DefaultTimeout(30 * time.Minute),
 		},
 	}
 }
 
-func resourceFTDDeviceCreate(d *schema.ResourceData, m interface{}) error {
+func resourceFTDDeviceCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	config := m.(*ProviderConfig)
 
-	payload := map[string]interface{}{
-		"name":              d.Get("name").(string),
-		"serialNumber":      d.Get("serial_number").(string),
-		"fmcAccessPolicyUid": d.Get("access_policy_uuid").(string),
-		"licenses":          []string{"BASE"},
-		"adminPassword":     d.Get("admin_password").(string),
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
 	}
 
-	resp, err := makeRequest(
-		"POST",
-		fmt.Sprintf("%s/api/rest/v1/inventory/devices/ftds/ztp", config.BaseURL),
-		config.Token,
-		payload,
-	)
+	name := d.Get("name").(string)
+
+	var diags diag.Diagnostics
+	duplicates, err := listDevices(ctx, config, inventoryQuery("name", name))
 	if err != nil {
-		return fmt.Errorf("Error creating FTD device: %s", err)
+		logAPIEvent(ctx, config, "WARN", "Could not check for devices with a duplicate name", map[string]interface{}{
+			"name":  name,
+			"error": err.Error(),
+		})
+	} else if len(duplicates) > 0 {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Duplicate device name",
+			Detail:   fmt.Sprintf("%d device(s) named %q already exist in CDO. Device names don't have to be unique, but the new device will be hard to tell apart from the existing one.", len(duplicates), name),
+		})
 	}
 
-	var transaction TransactionResponse
-	if err := json.Unmarshal(resp, &transaction); err != nil {
-		return fmt.Errorf("Error parsing response: %s", err)
+	err = createFTDDevice(ctx, d, config)
+	if errors.Is(err, errRegistrationPending) {
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "FTD device has not registered yet",
+			Detail:   fmt.Sprintf("FTD device %s was claimed with serial number %s but hasn't called home yet. It is kept in state, and claim_state changes to %s on the first refresh after it registers.", d.Id(), d.Get("serial_number").(string), claimStateRegistered),
+		})
 	}
+	notifyOnboardingResult(ctx, config, OnboardingSummary{
+		Resource:     "cdo_ftd_device",
+		Name:         name,
+		SerialNumber: d.Get("serial_number").(string),
+		DeviceUID:    d.Id(),
+	}, err)
+	if err != nil {
+		diags = append(diags, errorDiagnostics("Error creating FTD device", err)...)
+		if d.Id() != "" && d.Get("auto_cleanup_on_failure").(bool) {
+			diags = append(diags, cleanupFailedFTDDevice(ctx, d, config)...)
+		}
+	}
+	return diags
+}
+
+// cleanupFailedFTDDevice deletes a device whose onboarding failed, so that it
+// is neither left in CDO nor kept in state.
+func cleanupFailedFTDDevice(ctx context.Context, d *schema.ResourceData, config *ProviderConfig) diag.Diagnostics {
+	err := deleteInitialRules(ctx, config, d)
+	if err == nil {
+		err = deleteDevice(ctx, config, ftdDeviceTypeFor(d), d.Id(), deleteWait(d))
+	}
+	if err == nil {
+		err = waitForFTDDeviceDeleted(ctx, config, d.Id())
+	}
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Failed FTD device was not cleaned up",
+			Detail:   fmt.Sprintf("Deleting FTD device %s after its onboarding failed returned an error, it is kept in state as tainted: %s", d.Id(), err),
+		}}
+	}
+
+	d.SetId("")
+	return nil
+}
 
-	if err := pollTransaction(config.Token, transaction.TransactionPollingURL); err != nil {
-		d.SetId(transaction.EntityUid)
+func createFTDDevice(ctx context.Context, d *schema.ResourceData, config *ProviderConfig) error {
+	accessPolicyUUID := d.Get("access_policy_uuid").(string)
+	if accessPolicyUUID == "" {
+		accessPolicyUUID = config.DefaultAccessPolicyUUID
+	}
+	if accessPolicyUUID == "" {
+		return fmt.Errorf("access_policy_uuid must be set on the resource or as the provider's default_access_policy_uuid")
+	}
+	d.Set("access_policy_uuid", accessPolicyUUID)
+
+	// The device is onboarded on its staging policy, if any, and moved to
+	// access_policy_uuid by a later apply.
+	onboardingPolicyUUID := accessPolicyUUID
+	if staging, ok := d.GetOk("staging_access_policy_uuid"); ok {
+		onboardingPolicyUUID = staging.(string)
+	}
+
+	licenses := deviceLicenses(d, ftdDeviceTypeFor(d))
+	d.Set("licenses", licenses)
+
+	payload := ftdOnboardingPayload(d, onboardingPolicyUUID, licenses)
+	if d.Get("onboarding_method").(string) == onboardingMethodZTP {
+		payload["serialNumber"] = d.Get("serial_number").(string)
+		payload["adminPassword"] = d.Get("admin_password").(string)
+		if v, ok := d.GetOk("smart_license_token"); ok {
+			payload["smartLicenseToken"] = v.(string)
+		}
+		if v, ok := d.GetOk("license_reservation"); ok {
+			payload["licenseReservation"] = v.(string)
+		}
+	}
+	// The password and token are only needed for onboarding, keep them out
+	// of state.
+	d.Set("admin_password", "")
+	d.Set("smart_license_token", "")
+	if d.Get("management_mode").(string) == managementModeOnPremFMC {
+		payload["onPremFmcUid"] = d.Get("on_prem_fmc_uid").(string)
+	}
+
+	ztp := d.Get("onboarding_method").(string) == onboardingMethodZTP
+	wait := !ztp || d.Get("wait_for_registration").(bool)
+
+	var onboarding *OnboardingResponse
+	var err error
+	if wait {
+		onboarding, err = onboardDevice(ctx, config, ftdDeviceTypeFor(d), payload, withResourcePolling(transactionWait(config, d, schema.TimeoutCreate), d))
+	} else {
+		onboarding, err = submitOnboarding(ctx, config, ftdDeviceTypeFor(d), payload)
+	}
+	if onboarding != nil && onboarding.EntityUid != "" {
+		d.SetId(string(onboarding.EntityUid))
+		d.Set("bootstrap_data", onboarding.BootstrapData)
+		d.Set("registration_key", onboarding.RegistrationKey)
+		d.Set("cli_command", onboarding.CLICommand)
+		d.Set("initial_rule_ids", []string{})
+		d.Set("last_transaction_uid", string(onboarding.TransactionUid))
+		d.Set("state", transactionState(err))
+		d.Set("last_transaction_error", lastTransactionError(err))
+	}
+	// Without initial rules to deploy, a device that is still to call home
+	// is left for later refreshes to pick up.
+	if ztp && d.Id() != "" && len(initialRules(d)) == 0 && (!wait && err == nil || transactionState(err) == transactionStateTimeout) {
+		if !wait {
+			d.Set("state", transactionStatePending)
+		}
+		d.Set("claim_state", claimStatePendingRegistration)
+		return errRegistrationPending
+	}
+	if err != nil {
 		return err
 	}
+	if ztp {
+		d.Set("claim_state", claimStateRegistered)
+	}
+
+	d.Set("assigned_access_policy_uuid", onboardingPolicyUUID)
+
+	device, err := waitForFTDDevice(ctx, config, d.Id())
+	if err != nil {
+		return fmt.Errorf("Error reading onboarded FTD device: %w", err)
+	}
+	setFTDDeviceComputedState(d, device)
 
-	d.SetId(transaction.EntityUid)
+	// CustomizeDiff rejects initial_rules together with a staging policy, so
+	// the rules go on the policy that is deployed to the device.
+	if rules := initialRules(d); len(rules) > 0 {
+		ids, err := createAccessRules(ctx, config, accessPolicyUUID, rules)
+		if err != nil {
+			return err
+		}
+		d.Set("initial_rule_ids", ids)
+		d.Set("initial_rules_access_policy_uuid", accessPolicyUUID)
+		if err := deployDevice(ctx, config, d.Id(), withResourcePolling(transactionWait(config, d, schema.TimeoutCreate), d)); err != nil {
+			d.Set("last_transaction_error", lastTransactionError(err))
+			return err
+		}
+	}
 	return nil
 }
 
-func resourceFTDDeviceRead(d *schema.ResourceData, m interface{}) error {
-	// In this implementation, we'll assume the device exists if we successfully created it
-	// A more complete implementation would verify the device's existence via API
+// deleteInitialRules deletes the access rules created from initial_rules,
+// which would otherwise stay on the shared access policy after the device is
+// gone.
+func deleteInitialRules(ctx context.Context, config *ProviderConfig, d *schema.ResourceData) error {
+	var ids []string
+	for _, id := range d.Get("initial_rule_ids").([]interface{}) {
+		ids = append(ids, id.(string))
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := deleteAccessRules(ctx, config, d.Get("initial_rules_access_policy_uuid").(string), ids); err != nil {
+		return err
+	}
+	d.Set("initial_rule_ids", nil)
 	return nil
 }
 
-func resourceFTDDeviceDelete(d *schema.ResourceData, m interface{}) error {
+func initialRules(d *schema.ResourceData) []AccessRule {
+	var rules []AccessRule
+	for _, v := range d.Get("initial_rules").([]interface{}) {
+		rule := v.(map[string]interface{})
+		rules = append(rules, AccessRule{
+			Name:                rule["name"].(string),
+			Type:                "AccessRule",
+			Action:              rule["action"].(string),
+			Enabled:             rule["enabled"].(bool),
+			SourceNetworks:      networkLiteralsFor(rule["source_networks"].([]interface{})),
+			DestinationNetworks: networkLiteralsFor(rule["destination_networks"].([]interface{})),
+		})
+	}
+	return rules
+}
+
+func resourceFTDDeviceRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	config := m.(*ProviderConfig)
 
-	resp, err := makeRequest(
-		"POST",
-		fmt.Sprintf("%s/api/rest/v1/inventory/devices/ftds/cdfmcManaged/%s/delete", config.BaseURL, d.Id()),
-		config.Token,
-		nil,
-	)
+	device, err := getFTDDevice(ctx, config, d.Id())
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] FTD device %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("Error deleting FTD device: %s", err)
+		return errorDiagnostics("Error reading FTD device", err)
 	}
 
-	if bytes.Equal(resp, []byte("success")) {
-		d.SetId("")
+	// A create that gave up waiting on onboarding is resolved once the device
+	// has come online.
+	if state := d.Get("state").(string); (state == transactionStatePending || state == transactionStateTimeout) && device.ConnectivityState == connectivityStateOnline {
+		d.Set("state", transactionStateDone)
+		if d.Get("claim_state").(string) == claimStatePendingRegistration {
+			d.Set("claim_state", claimStateRegistered)
+		}
+	}
+
+	// A device still on its staging policy is expected to differ from
+	// access_policy_uuid; the pending move shows up through
+	// assigned_access_policy_uuid instead.
+	target := d.Get("access_policy_uuid").(string)
+	setFTDDeviceState(d, device)
+	if staging := d.Get("staging_access_policy_uuid").(string); staging != "" && device.AccessPolicyUid == staging {
+		d.Set("access_policy_uuid", target)
+	}
+
+	if err := setFTDDeviceAccessPolicy(ctx, config, d, device.AccessPolicyUid); err != nil {
+		return errorDiagnostics("Error reading access policy", err)
+	}
+	return nil
+}
+
+// setFTDDeviceAccessPolicy sets the details of the access policy the device
+// is assigned to. Policies of on-prem FMCs aren't known to CDO, so they are
+// left empty.
+func setFTDDeviceAccessPolicy(ctx context.Context, config *ProviderConfig, d *schema.ResourceData, uid string) error {
+	var policy AccessPolicy
+	if uid != "" && d.Get("management_mode").(string) != managementModeOnPremFMC {
+		err := getCDFMCObject(ctx, config, accessPolicyPath, uid, &policy)
+		if err != nil && !errors.Is(err, errNotFound) {
+			return err
+		}
+	}
+
+	d.Set("access_policy_name", policy.Name)
+	d.Set("access_policy_description", policy.Description)
+	d.Set("access_policy_type", policy.Type)
+	return nil
+}
+
+func resourceFTDDeviceUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	payload := map[string]interface{}{}
+	if d.HasChange("name") {
+		payload["name"] = d.Get("name").(string)
+	}
+	if d.HasChanges("access_policy_uuid", "assigned_access_policy_uuid") {
+		payload["fmcAccessPolicyUid"] = d.Get("access_policy_uuid").(string)
+	}
+	if d.HasChange("description") {
+		payload["description"] = d.Get("description").(string)
+	}
+	if d.HasChange("labels") {
+		payload["labels"] = deviceLabels(d.Get("labels").(*schema.Set))
+	}
+	if d.HasChange("licenses") {
+		payload["licenses"] = deviceLicenses(d, ftdDeviceTypeFor(d))
+	}
+	if d.HasChange("admin_password_version") {
+		password, err := configuredAdminPassword(d.GetRawConfig())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		payload["adminPassword"] = password
+	}
+	if len(payload) == 0 {
 		return nil
+	}
 
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"PATCH",
+		config.apiURL(fmt.Sprintf("/inventory/devices/ftds/%s", d.Id())),
+		payload,
+	)
+	if err != nil {
+		d.Partial(true)
+		return errorDiagnostics("Error updating FTD device", err)
 	}
 
 	var transaction TransactionResponse
-	if err := json.Unmarshal(resp, &transaction); err != nil {
-		return fmt.Errorf("Error parsing response: %s", err)
+	if err := decodeJSON(resp, &transaction); err != nil {
+		d.Partial(true)
+		return diag.Errorf("Error parsing response: %s", err)
 	}
 
-	if err := pollTransaction(config.Token, transaction.TransactionPollingURL); err != nil {
-		return err
+	if transaction.TransactionPollingURL != "" {
+		err := waitFor(ctx, config, transaction.TransactionPollingURL, withResourcePolling(transactionWait(config, d, schema.TimeoutUpdate), d))
+		d.Set("last_transaction_error", lastTransactionError(err))
+		if err != nil {
+			d.Partial(true)
+			return errorDiagnostics("Error updating FTD device", err)
+		}
 	}
 
-	d.SetId("")
+	d.Set("assigned_access_policy_uuid", d.Get("access_policy_uuid").(string))
 	return nil
 }
 
-func makeRequest(method, url, token string, payload interface{}) ([]byte, error) {
-	var body io.Reader
-	if payload != nil {
-		payloadBytes, err := json.Marshal(payload)
+// resourceFTDDeviceImport populates state from the device record, so that
+// importing with only the device UID yields complete state. Devices can also
+// be imported by serial number with an ID of the form "serial:<serial>".
+func resourceFTDDeviceImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	config := m.(*ProviderConfig)
+
+	if serial, ok := strings.CutPrefix(d.Id(), serialImportPrefix); ok {
+		uid, err := findDeviceUIDBySerial(ctx, config, serial)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("Error importing FTD device: %s", err)
 		}
-		body = strings.NewReader(string(payloadBytes))
+		d.SetId(uid)
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	device, err := getFTDDevice(ctx, config, d.Id())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Error importing FTD device: %s", err)
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	if payload != nil {
-		req.Header.Set("Content-Type", "application/json")
+	setFTDDeviceState(d, device)
+	// Rules of imported devices weren't created from initial_rules.
+	d.Set("initial_rule_ids", []string{})
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceFTDDeviceCustomizeDiff checks the onboarding settings of new
+// devices, and plans a policy reassignment whenever the policy the device is
+// actually on differs from access_policy_uuid, which is the case after
+// onboarding with a staging_access_policy_uuid. The provider's default access
+// policy is filled in by Create, as access_policy_uuid is unknown at plan
+// time when it isn't set.
+func resourceFTDDeviceCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	config := m.(*ProviderConfig)
+
+	if d.Id() == "" {
+		if d.Get("onboarding_method").(string) == onboardingMethodZTP {
+			if d.NewValueKnown("serial_number") && d.Get("serial_number").(string) == "" {
+				return fmt.Errorf("serial_number is required when onboarding_method is %q", onboardingMethodZTP)
+			}
+			if !d.Get("wait_for_registration").(bool) && len(d.Get("initial_rules").([]interface{})) > 0 {
+				return fmt.Errorf("initial_rules can only be deployed when wait_for_registration is true")
+			}
+		} else {
+			for _, attr := range []string{"smart_license_token", "license_reservation"} {
+				if _, ok := d.GetOk(attr); ok {
+					return fmt.Errorf("%s can only be used when onboarding_method is %q", attr, onboardingMethodZTP)
+				}
+			}
+			if d.Get("management_mode").(string) != managementModeCDFMC {
+				return fmt.Errorf("onboarding_method %q is only supported for devices managed by the cloud-delivered FMC", onboardingMethodRegistrationKey)
+			}
+			if len(d.Get("initial_rules").([]interface{})) > 0 {
+				return fmt.Errorf("initial_rules can't be deployed to a device onboarded with onboarding_method %q, which only registers once cli_command has been run", onboardingMethodRegistrationKey)
+			}
+		}
+		if d.Get("management_mode").(string) == managementModeOnPremFMC {
+			if d.NewValueKnown("on_prem_fmc_uid") && d.Get("on_prem_fmc_uid").(string) == "" {
+				return fmt.Errorf("on_prem_fmc_uid is required when management_mode is %q", managementModeOnPremFMC)
+			}
+			if len(d.Get("initial_rules").([]interface{})) > 0 {
+				return fmt.Errorf("initial_rules can only be used with devices managed by the cloud-delivered FMC")
+			}
+		}
+		if _, ok := d.GetOk("staging_access_policy_uuid"); ok && len(d.Get("initial_rules").([]interface{})) > 0 {
+			return fmt.Errorf("initial_rules can't be used with staging_access_policy_uuid, as the device is deployed with its staging policy rather than access_policy_uuid")
+		}
+		return nil
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	if d.HasChange("admin_password_version") {
+		if _, err := configuredAdminPassword(d.GetRawConfig()); err != nil {
+			return err
+		}
+	}
+
+	if replaced := ftdDeviceReplacedBy(d); len(replaced) > 0 {
+		logAPIEvent(ctx, config, "WARN", "Change forces replacement of an onboarded FTD device", map[string]interface{}{
+			"device_uid": d.Id(),
+			"attributes": replaced,
+		})
+		if config.PreventDeviceReplacement && !d.Get("allow_replacement").(bool) {
+			return fmt.Errorf("changing %s would delete and re-onboard FTD device %s, interrupting its traffic. Set allow_replacement = true on the resource to go ahead", strings.Join(replaced, ", "), d.Id())
+		}
+	}
+
+	assigned := d.Get("assigned_access_policy_uuid").(string)
+	target := d.Get("access_policy_uuid").(string)
+	if assigned != "" && target != "" && assigned != target {
+		return d.SetNew("assigned_access_policy_uuid", target)
+	}
+	return nil
+}
+
+func resourceFTDDeviceDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := deleteInitialRules(ctx, config, d); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := deleteDevice(ctx, config, ftdDeviceTypeFor(d), d.Id(), deleteWait(d)); err != nil {
+		return errorDiagnostics("Error deleting FTD device", err)
+	}
+	if err := waitForFTDDeviceDeleted(ctx, config, d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func getFTDDevice(ctx context.Context, config *ProviderConfig, uid string) (*FTDDevice, error) {
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/inventory/devices/ftds/%s", uid)),
+		nil,
+	)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	acceptableResponseCodes := map[int]struct{}{
-		http.StatusOK:                  {},
-		http.StatusCreated:             {},
-		http.StatusAccepted:            {},
-		http.StatusNonAuthoritativeInfo: {},
-		http.StatusNoContent:           {},
-		http.StatusResetContent:        {},
-		http.StatusPartialContent:      {},
+	var device FTDDevice
+	if err := decodeJSON(resp, &device); err != nil {
+		return nil, fmt.Errorf("Error parsing response: %s", err)
 	}
-	if _, ok := acceptableResponseCodes[resp.StatusCode]; !ok {
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+	return &device, nil
+}
+
+func findDeviceUIDBySerial(ctx context.Context, config *ProviderConfig, serial string) (string, error) {
+	return findDeviceUID(ctx, config, "serial", "serial number", serial)
+}
+
+func findDeviceUIDByName(ctx context.Context, config *ProviderConfig, name string) (string, error) {
+	return findDeviceUID(ctx, config, "name", "name", name)
+}
+
+// findDeviceUID returns the UID of the only device whose inventory field
+// has the given value, described as label in errors.
+func findDeviceUID(ctx context.Context, config *ProviderConfig, field, label, value string) (string, error) {
+	devices, err := listDevices(ctx, config, inventoryQuery(field, value))
+	if err != nil {
+		return "", err
 	}
 
-	if resp.Body == nil {
-		return []byte("success"), nil
+	switch len(devices) {
+	case 0:
+		return "", fmt.Errorf("no device with %s %q found", label, value)
+	case 1:
+		return string(devices[0].Uid), nil
+	default:
+		return "", fmt.Errorf("%d devices with %s %q found", len(devices), label, value)
 	}
+}
 
-	return io.ReadAll(resp.Body)
+// waitForFTDDevice reads a freshly onboarded device, tolerating the 404s
+// returned until the inventory has indexed it.
+func waitForFTDDevice(ctx context.Context, config *ProviderConfig, uid string) (*FTDDevice, error) {
+	deadline := time.Now().Add(deviceIndexTimeout)
+	for {
+		device, err := getFTDDevice(ctx, config, uid)
+		if !errors.Is(err, errNotFound) || time.Now().After(deadline) {
+			return device, err
+		}
+		if err := sleepContext(ctx, deviceIndexInterval); err != nil {
+			return nil, err
+		}
+	}
 }
 
-func pollTransaction(token, pollingURL string) error {
-	maxAttempts := 30
-	delaySeconds := 10
+func deviceLabels(labels *schema.Set) Labels {
+	result := Labels{UngroupedLabels: []string{}}
+	for _, v := range labels.List() {
+		result.UngroupedLabels = append(result.UngroupedLabels, v.(string))
+	}
+	sort.Strings(result.UngroupedLabels)
+	return result
+}
 
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		resp, err := makeRequest("GET", pollingURL, token, nil)
-		if err != nil {
-			return err
-		}
+// configuredAdminPassword returns admin_password from the configuration,
+// since it is blanked in state and its changes are suppressed after create.
+func configuredAdminPassword(config cty.Value) (string, error) {
+	password := config.GetAttr("admin_password")
+	if !password.IsKnown() {
+		return "", nil
+	}
+	if password.IsNull() || password.AsString() == "" {
+		return "", fmt.Errorf("admin_password must be set to change admin_password_version")
+	}
+	return password.AsString(), nil
+}
+
+// suppressAfterCreate hides the diff of write-only attributes, which are
+// blanked in state once the resource has been created.
+func suppressAfterCreate(_, old, _ string, d *schema.ResourceData) bool {
+	return d.Id() != "" && old == ""
+}
+
+// ftdDeviceReplacementAttributes are the attributes that can't be changed on
+// an onboarded device, so that changing them deletes and re-onboards it.
+var ftdDeviceReplacementAttributes = []string{
+	"serial_number",
+	"onboarding_method",
+	"management_mode",
+	"on_prem_fmc_uid",
+	"staging_access_policy_uuid",
+	"dns_servers",
+	"initial_rules",
+	"performance_tier",
+	"license_reservation",
+}
 
-		var transaction TransactionResponse
-		if err := json.Unmarshal(resp, &transaction); err != nil {
-			return fmt.Errorf("Error parsing polling response: %s", err)
+// ftdDeviceReplacedBy returns the attributes whose change forces the device
+// to be replaced.
+func ftdDeviceReplacedBy(d *schema.ResourceDiff) []string {
+	var replaced []string
+	for _, attr := range ftdDeviceReplacementAttributes {
+		if d.HasChange(attr) {
+			replaced = append(replaced, attr)
 		}
+	}
+	return replaced
+}
 
-		if transaction.CDOTransactionStatus == "DONE" {
+// waitForFTDDeviceDeleted confirms that a deleted device has left the
+// inventory, allowing it the same time to disappear as a new device gets to
+// show up.
+func waitForFTDDeviceDeleted(ctx context.Context, config *ProviderConfig, uid string) error {
+	deadline := time.Now().Add(deviceIndexTimeout)
+	for {
+		_, err := getFTDDevice(ctx, config, uid)
+		if errors.Is(err, errNotFound) {
 			return nil
 		}
-		if transaction.CDOTransactionStatus == "ERROR" {
-			return fmt.Errorf("Transaction failed with status ERROR")
+		if err != nil {
+			return fmt.Errorf("Error confirming FTD device deletion: %s", err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("FTD device %s still exists after its delete transaction completed", uid)
 		}
+		if err := sleepContext(ctx, deviceIndexInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// ftdOnboardingPayload returns the parts of an FTD onboarding request that
+// don't depend on the onboarding method or on secrets. It is shared with the
+// cdo_ftd_ztp_config data source, which renders the request for review.
+func ftdOnboardingPayload(d *schema.ResourceData, accessPolicyUUID string, licenses []string) map[string]interface{} {
+	payload := map[string]interface{}{
+		"name":               d.Get("name").(string),
+		"fmcAccessPolicyUid": accessPolicyUUID,
+		"licenses":           licenses,
+	}
+	if v, ok := d.GetOk("labels"); ok {
+		payload["labels"] = deviceLabels(v.(*schema.Set))
+	}
+	if v, ok := d.GetOk("description"); ok {
+		payload["description"] = v.(string)
+	}
+	if v, ok := d.GetOk("dns_servers"); ok {
+		payload["dnsServers"] = v.([]interface{})
+	}
+	if v, ok := d.GetOk("performance_tier"); ok {
+		payload["performanceTier"] = v.(string)
+	}
+	return payload
+}
+
+// ftdDeviceTypeFor returns the endpoints matching the device's
+// management_mode.
+func ftdDeviceTypeFor(d *schema.ResourceData) DeviceType {
+	if d.Get("management_mode").(string) == managementModeOnPremFMC {
+		return onPremFMCFTDDeviceType
+	}
+	if d.Get("onboarding_method").(string) == onboardingMethodRegistrationKey {
+		return registrationKeyFTDDeviceType
+	}
+	return ftdDeviceType
+}
+
+func setFTDDeviceState(d *schema.ResourceData, device *FTDDevice) {
+	d.Set("name", device.Name)
+	d.Set("description", device.Description)
+	d.Set("serial_number", device.SerialNumber)
+	d.Set("access_policy_uuid", device.AccessPolicyUid)
+	d.Set("assigned_access_policy_uuid", device.AccessPolicyUid)
+	d.Set("labels", device.Labels.UngroupedLabels)
+	if len(device.Licenses) > 0 {
+		licenses := make([]string, 0, len(device.Licenses))
+		for _, license := range device.Licenses {
+			licenses = append(licenses, license.Name)
+		}
+		d.Set("licenses", licenses)
+	}
+	setFTDDeviceComputedState(d, device)
+}
 
-		time.Sleep(time.Duration(delaySeconds) * time.Second)
+// setFTDDeviceComputedState sets the attributes that are only ever reported
+// by CDO, never configured.
+func setFTDDeviceComputedState(d *schema.ResourceData, device *FTDDevice) {
+	licenseStatus := make(map[string]string, len(device.Licenses))
+	for _, license := range device.Licenses {
+		licenseStatus[license.Name] = license.Status
 	}
 
-	return fmt.Errorf("Transaction polling timed out after %d attempts", maxAttempts)
+	d.Set("capabilities", device.Capabilities)
+	d.Set("license_status", licenseStatus)
+	d.Set("connectivity_state", device.ConnectivityState)
+	d.Set("software_version", device.SoftwareVersion)
+	d.Set("cdfmc_device_uid", device.UidOnFmc)
+	d.Set("lar_type", device.LarType)
 }
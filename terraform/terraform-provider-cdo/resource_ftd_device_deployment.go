@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// resourceFTDDeviceDeployment deploys the pending changes of a cdFMC managed
+// FTD. A deployment is performed on create and again whenever device_uid or
+// triggers change.
+func resourceFTDDeviceDeployment() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceFTDDeviceDeploymentCreate,
+		ReadContext:   resourceFTDDeviceDeploymentRead,
+		DeleteContext: resourceFTDDeviceDeploymentDelete,
+
+		Schema: map[string]*schema.Schema{
+			"device_uid": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values that trigger a new deployment when changed.",
+			},
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+func resourceFTDDeviceDeploymentCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := deployDevice(ctx, config, d.Get("device_uid").(string), transactionWait(config, d, schema.TimeoutCreate)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(id.UniqueId())
+	return nil
+}
+
+func resourceFTDDeviceDeploymentRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// A deployment is a one-off action, there is nothing to read back.
+	return nil
+}
+
+func resourceFTDDeviceDeploymentDelete(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
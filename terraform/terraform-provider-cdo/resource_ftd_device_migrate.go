@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// resourceFTDDeviceV0 is the schema cdo_ftd_device states were written with
+// before the resource was versioned.
+func resourceFTDDeviceV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"serial_number": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"access_policy_uuid": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"admin_password": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+// resourceFTDDeviceStateUpgradeV0 fills in the attributes that didn't exist
+// in version 0 with the values matching how those devices were onboarded, so
+// that upgrading doesn't plan to replace them, and drops the admin password
+// version 0 kept in state.
+func resourceFTDDeviceStateUpgradeV0(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+	if rawState == nil {
+		return rawState, nil
+	}
+
+	rawState["admin_password"] = ""
+	if _, ok := rawState["management_mode"]; !ok {
+		rawState["management_mode"] = managementModeCDFMC
+	}
+	if _, ok := rawState["onboarding_method"]; !ok {
+		rawState["onboarding_method"] = onboardingMethodZTP
+	}
+	if _, ok := rawState["assigned_access_policy_uuid"]; !ok {
+		rawState["assigned_access_policy_uuid"] = rawState["access_policy_uuid"]
+	}
+	return rawState, nil
+}
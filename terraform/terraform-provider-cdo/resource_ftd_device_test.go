@@ -0,0 +1,573 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func init() {
+	resource.AddTestSweepers("cdo_ftd_device", &resource.Sweeper{
+		Name: "cdo_ftd_device",
+		F:    sweepFTDDevices,
+	})
+}
+
+// sweepFTDDevices deletes the cdFMC managed FTDs left behind by acceptance
+// tests that failed before destroying them.
+func sweepFTDDevices(_ string) error {
+	config, err := sharedConfig()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	devices, err := listDevices(ctx, config, inventoryQuery("deviceType", cdfmcFTDDeviceType))
+	if err != nil {
+		return fmt.Errorf("Error listing FTD devices: %s", err)
+	}
+
+	var errs []string
+	for _, device := range devices {
+		if !strings.HasPrefix(device.Name, testAccNamePrefix) {
+			continue
+		}
+		log.Printf("[INFO] Deleting FTD device %s (%s)", device.Name, device.Uid)
+		if err := deleteDevice(ctx, config, ftdDeviceType, string(device.Uid), cdoTransactionWait); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", device.Uid, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Error deleting FTD devices: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func TestAccFTDDevice_registrationKey(t *testing.T) {
+	name := acctest.RandomWithPrefix(testAccNamePrefix + "ftd")
+	accessPolicyUUID := os.Getenv("CDO_TEST_ACCESS_POLICY_UUID")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testAccPreCheckAccessPolicy(t)
+		},
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckFTDDeviceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFTDDeviceConfig(name, accessPolicyUUID, "created by acceptance tests"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("cdo_ftd_device.test", "name", name),
+					resource.TestCheckResourceAttr("cdo_ftd_device.test", "access_policy_uuid", accessPolicyUUID),
+					resource.TestCheckResourceAttrSet("cdo_ftd_device.test", "cli_command"),
+				),
+			},
+			{
+				Config: testAccFTDDeviceConfig(name, accessPolicyUUID, "updated by acceptance tests"),
+				Check:  resource.TestCheckResourceAttr("cdo_ftd_device.test", "description", "updated by acceptance tests"),
+			},
+			{
+				ResourceName:      "cdo_ftd_device.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"onboarding_method",
+					"registration_key",
+					"cli_command",
+					"bootstrap_data",
+					"state",
+					"last_transaction_uid",
+					"last_transaction_error",
+					"wait_for_registration",
+					"allow_replacement",
+					"auto_cleanup_on_failure",
+				},
+			},
+		},
+	})
+}
+
+func testAccFTDDeviceConfig(name, accessPolicyUUID, description string) string {
+	return fmt.Sprintf(`
+resource "cdo_ftd_device" "test" {
+  name               = %q
+  onboarding_method  = "registration_key"
+  access_policy_uuid = %q
+  description        = %q
+}
+`, name, accessPolicyUUID, description)
+}
+
+func testAccCheckFTDDeviceDestroy(s *terraform.State) error {
+	config, err := sharedConfig()
+	if err != nil {
+		return err
+	}
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "cdo_ftd_device" {
+			continue
+		}
+		_, err := getFTDDevice(context.Background(), config, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("FTD device %s still exists", rs.Primary.ID)
+		}
+		if !errors.Is(err, errNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+const testAccessPolicyUUID = "7a1b2c3d-0000-4000-8000-000000000001"
+
+// ftdDeviceHandler serves the requests made while creating an FTD device
+// whose onboarding transaction ends in status. The onboarding payload is
+// stored in payload.
+func ftdDeviceHandler(t *testing.T, status string, payload *map[string]interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/rest/v1/inventory/devices":
+			w.Write([]byte(`{"count":0,"items":[]}`))
+		case r.Method == "POST" && r.URL.Path == "/api/rest/v1/inventory/devices/ftds/ztp":
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, payload); err != nil {
+				t.Errorf("onboarding payload %s: %s", body, err)
+			}
+			w.Write([]byte(`{"transactionPollingUrl":"http://` + r.Host + testTransactionPath + `","entityUid":"device-1"}`))
+		case r.Method == "GET" && r.URL.Path == testTransactionPath:
+			w.Write([]byte(`{"cdoTransactionStatus":"` + status + `","errorMessage":"Registration failed"}`))
+		case r.Method == "GET" && r.URL.Path == "/api/rest/v1/inventory/devices/ftds/device-1":
+			w.Write([]byte(`{"uid":"device-1","name":"ftd-1","serial":"JAD1234","fmcAccessPolicyUid":"` + testAccessPolicyUUID + `","licenses":[{"name":"BASE","status":"IN_COMPLIANCE"}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func testFTDDeviceData(t *testing.T) *schema.ResourceData {
+	return schema.TestResourceDataRaw(t, resourceFTDDevice().Schema, map[string]interface{}{
+		"name":               "ftd-1",
+		"serial_number":      "JAD1234",
+		"access_policy_uuid": testAccessPolicyUUID,
+		"admin_password":     "Secret123!",
+	})
+}
+
+func TestFTDDeviceCreate(t *testing.T) {
+	var payload map[string]interface{}
+	config := newTestConfig(t, ftdDeviceHandler(t, "DONE", &payload))
+	d := testFTDDeviceData(t)
+
+	if diags := resourceFTDDeviceCreate(context.Background(), d, config); diags.HasError() {
+		t.Fatalf("Create() error = %v", diags)
+	}
+
+	if d.Id() != "device-1" {
+		t.Errorf("Id() = %q, want the onboarded entity", d.Id())
+	}
+	for key, want := range map[string]string{
+		"state":                       transactionStateDone,
+		"assigned_access_policy_uuid": testAccessPolicyUUID,
+		"license_status.BASE":         "IN_COMPLIANCE",
+		"admin_password":              "",
+	} {
+		if got := d.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	for key, want := range map[string]interface{}{
+		"name":               "ftd-1",
+		"serialNumber":       "JAD1234",
+		"fmcAccessPolicyUid": testAccessPolicyUUID,
+		"adminPassword":      "Secret123!",
+	} {
+		if payload[key] != want {
+			t.Errorf("payload[%s] = %v, want %v", key, payload[key], want)
+		}
+	}
+}
+
+func TestFTDDeviceCreateFailedTransaction(t *testing.T) {
+	var payload map[string]interface{}
+	config := newTestConfig(t, ftdDeviceHandler(t, "ERROR", &payload))
+	d := testFTDDeviceData(t)
+
+	if diags := resourceFTDDeviceCreate(context.Background(), d, config); !diags.HasError() {
+		t.Fatal("Create() succeeded, want the transaction error")
+	}
+
+	if d.Id() != "device-1" {
+		t.Errorf("Id() = %q, want the partially onboarded device kept in state", d.Id())
+	}
+	if got := d.Get("state"); got != transactionStateError {
+		t.Errorf("state = %q, want %q", got, transactionStateError)
+	}
+	if got := d.Get("last_transaction_error"); got != "Registration failed" {
+		t.Errorf("last_transaction_error = %q, want the transaction's error message", got)
+	}
+}
+
+func TestSetFTDDeviceAccessPolicy(t *testing.T) {
+	const policyUID = "00000000-0000-0000-0000-000000000001"
+	policyPath := fmt.Sprintf("/cdfmc/api/fmc_config/v1/domain/%s%s/%s", cdfmcGlobalDomainUID, accessPolicyPath, policyUID)
+
+	tests := []struct {
+		name           string
+		managementMode string
+		policyUID      string
+		wantName       string
+		wantRequest    bool
+	}{
+		{name: "cdFMC", managementMode: managementModeCDFMC, policyUID: policyUID, wantName: "Branch Policy", wantRequest: true},
+		{name: "deleted policy", managementMode: managementModeCDFMC, policyUID: "00000000-0000-0000-0000-000000000002", wantRequest: true},
+		{name: "on-prem FMC", managementMode: managementModeOnPremFMC, policyUID: policyUID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, config := newMockCDO(t)
+			mock.Handle("GET", policyPath, func(*mockCDO, map[string]interface{}) (int, interface{}) {
+				return http.StatusOK, AccessPolicy{ID: policyUID, Name: "Branch Policy", Type: "AccessPolicy", Description: "Branches"}
+			})
+
+			d := resourceFTDDevice().TestResourceData()
+			d.Set("management_mode", tt.managementMode)
+			d.Set("access_policy_name", "stale")
+			if err := setFTDDeviceAccessPolicy(context.Background(), config, d, tt.policyUID); err != nil {
+				t.Fatalf("setFTDDeviceAccessPolicy() error = %s", err)
+			}
+
+			if got := d.Get("access_policy_name").(string); got != tt.wantName {
+				t.Errorf("access_policy_name = %q, want %q", got, tt.wantName)
+			}
+			if got := len(mock.Requests()) > 0; got != tt.wantRequest {
+				t.Errorf("requests = %v, want a policy lookup %t", mock.Requests(), tt.wantRequest)
+			}
+		})
+	}
+}
+
+func TestCreateFTDDeviceDefaultAccessPolicy(t *testing.T) {
+	const defaultPolicyUID = "00000000-0000-0000-0000-000000000001"
+
+	tests := []struct {
+		name          string
+		defaultPolicy string
+		wantPolicy    string
+		wantRequest   bool
+	}{
+		{name: "provider default", defaultPolicy: defaultPolicyUID, wantPolicy: defaultPolicyUID, wantRequest: true},
+		{name: "no default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, config := newMockCDO(t)
+			config.DefaultAccessPolicyUUID = tt.defaultPolicy
+			mock.Handle("POST", registrationKeyFTDDeviceType.OnboardPath, func(*mockCDO, map[string]interface{}) (int, interface{}) {
+				return http.StatusBadRequest, map[string]string{"errorCode": "INVALID_INPUT"}
+			})
+
+			d := resourceFTDDevice().TestResourceData()
+			d.Set("name", "ftd-1")
+			d.Set("onboarding_method", onboardingMethodRegistrationKey)
+			if err := createFTDDevice(context.Background(), d, config); err == nil {
+				t.Fatal("createFTDDevice() succeeded, want an error")
+			}
+
+			if got := d.Get("access_policy_uuid").(string); got != tt.wantPolicy {
+				t.Errorf("access_policy_uuid = %q, want %q", got, tt.wantPolicy)
+			}
+			if got := len(mock.Requests()) > 0; got != tt.wantRequest {
+				t.Errorf("requests = %v, want an onboarding attempt %t", mock.Requests(), tt.wantRequest)
+			}
+		})
+	}
+}
+
+func TestFTDDeviceInitialRulesAreDeleted(t *testing.T) {
+	mock, config := newMockCDO(t)
+	rulesPath := fmt.Sprintf("/cdfmc/api/fmc_config/v1/domain/%s%s/%s/accessrules", cdfmcGlobalDomainUID, accessPolicyPath, testAccessPolicyUUID)
+	mock.Handle("POST", rulesPath, func(_ *mockCDO, _ map[string]interface{}) (int, interface{}) {
+		return http.StatusCreated, accessRulePage{Items: []AccessRule{{ID: "rule-1", Name: "allow-dns"}, {ID: "rule-2", Name: "block-all"}}}
+	})
+	mock.Handle("DELETE", rulesPath+"/rule-1", func(*mockCDO, map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, nil
+	})
+
+	ids, err := createAccessRules(context.Background(), config, testAccessPolicyUUID, []AccessRule{{Name: "allow-dns"}, {Name: "block-all"}})
+	if err != nil {
+		t.Fatalf("createAccessRules() error = %s", err)
+	}
+
+	d := resourceFTDDevice().TestResourceData()
+	d.Set("initial_rule_ids", ids)
+	d.Set("initial_rules_access_policy_uuid", testAccessPolicyUUID)
+	// rule-2 is already gone, which must not fail the delete.
+	if err := deleteInitialRules(context.Background(), config, d); err != nil {
+		t.Fatalf("deleteInitialRules() error = %s", err)
+	}
+
+	sent := map[string]bool{}
+	for _, request := range mock.Requests() {
+		sent[request] = true
+	}
+	for _, id := range []string{"rule-1", "rule-2"} {
+		if want := "DELETE " + rulesPath + "/" + id; !sent[want] {
+			t.Errorf("no %s request sent, got %v", want, mock.Requests())
+		}
+	}
+	if got := d.Get("initial_rule_ids").([]interface{}); len(got) != 0 {
+		t.Errorf("initial_rule_ids = %v, want none left", got)
+	}
+}
+
+func TestFTDDeviceInitialRulesWithStagingPolicy(t *testing.T) {
+	_, config := newMockCDO(t)
+
+	_, err := resourceFTDDevice().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":                       "ftd-1",
+		"serial_number":              "JAD1234",
+		"access_policy_uuid":         testAccessPolicyUUID,
+		"staging_access_policy_uuid": "7a1b2c3d-0000-4000-8000-000000000002",
+		"initial_rules": []interface{}{
+			map[string]interface{}{"name": "allow-dns", "action": ruleActionAllow},
+		},
+	}), config)
+	if err == nil || !strings.Contains(err.Error(), "staging_access_policy_uuid") {
+		t.Fatalf("Diff() error = %v, want initial_rules rejected with a staging policy", err)
+	}
+}
+
+const testFTDDeviceUID = "00000000-0000-0000-0000-0000000000f1"
+
+// handleFTDOnboarding sets mock up to onboard FTD devices through ZTP: the
+// device is stored at its inventory path as testFTDDeviceUID, online and on
+// the requested policy, and the onboarding transaction is already done.
+func handleFTDOnboarding(mock *mockCDO, config *ProviderConfig) {
+	mock.Handle("POST", ftdDeviceType.OnboardPath, func(m *mockCDO, body map[string]interface{}) (int, interface{}) {
+		m.objects["/inventory/devices/ftds/"+testFTDDeviceUID] = map[string]interface{}{
+			"uid":                testFTDDeviceUID,
+			"name":               body["name"],
+			"serial":             body["serialNumber"],
+			"fmcAccessPolicyUid": body["fmcAccessPolicyUid"],
+			"connectivityState":  connectivityStateOnline,
+		}
+		return http.StatusOK, map[string]interface{}{
+			"entityUid":             testFTDDeviceUID,
+			"transactionPollingUrl": config.apiURL("/transactions/tx-1"),
+		}
+	})
+	mock.Handle("GET", "/transactions/tx-1", func(*mockCDO, map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"cdoTransactionStatus": "DONE"}
+	})
+}
+
+func TestFTDDevicePlansCleanlyWithoutInitialRules(t *testing.T) {
+	mock, config := newMockCDO(t)
+	handleFTDOnboarding(mock, config)
+	r := resourceFTDDevice()
+
+	raw := map[string]interface{}{
+		"name":               "ftd-1",
+		"serial_number":      "JAD2345678X",
+		"access_policy_uuid": testAccessPolicyUUID,
+		"admin_password":     "Secret123!",
+	}
+	state := mockApply(t, r, nil, raw, config)
+
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), config)
+	if err != nil {
+		t.Fatalf("Diff() error = %s", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("diff = %v, want no changes after create", diff)
+	}
+}
+
+func TestFTDDeviceRenameUpdatesInPlace(t *testing.T) {
+	mock, config := newMockCDO(t)
+	handleFTDOnboarding(mock, config)
+	r := resourceFTDDevice()
+
+	raw := map[string]interface{}{
+		"name":               "ftd-1",
+		"serial_number":      "JAD2345678X",
+		"access_policy_uuid": testAccessPolicyUUID,
+		"admin_password":     "Secret123!",
+	}
+	state := mockApply(t, r, nil, raw, config)
+
+	raw["name"] = "ftd-renamed"
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), config)
+	if err != nil {
+		t.Fatalf("Diff() error = %s", err)
+	}
+	if diff.RequiresNew() {
+		t.Fatalf("renaming plans a replacement: %v", diff)
+	}
+
+	updated := mockApply(t, r, state, raw, config)
+	if updated.ID != state.ID {
+		t.Errorf("ID = %q after rename, want it unchanged as %q", updated.ID, state.ID)
+	}
+	if got := updated.Attributes["name"]; got != "ftd-renamed" {
+		t.Errorf("name = %q, want ftd-renamed", got)
+	}
+	if got := mock.Object("/inventory/devices/ftds/" + testFTDDeviceUID)["name"]; got != "ftd-renamed" {
+		t.Errorf("device name = %v, want ftd-renamed", got)
+	}
+
+	var onboardings int
+	for _, request := range mock.Requests() {
+		if request == "POST "+ftdDeviceType.OnboardPath {
+			onboardings++
+		}
+		if strings.HasSuffix(request, "/delete") {
+			t.Errorf("rename sent %s, want no delete", request)
+		}
+	}
+	if onboardings != 1 {
+		t.Errorf("device onboarded %d times, want once", onboardings)
+	}
+}
+
+func TestFTDDeviceOnboardingLicenses(t *testing.T) {
+	tests := []struct {
+		name     string
+		licenses []interface{}
+		want     []interface{}
+	}{
+		{name: "default", want: []interface{}{"BASE"}},
+		{name: "configured", licenses: []interface{}{"THREAT", "BASE", "URLFilter"}, want: []interface{}{"BASE", "THREAT", "URLFilter"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, config := newMockCDO(t)
+			handleFTDOnboarding(mock, config)
+
+			raw := map[string]interface{}{
+				"name":               "ftd-1",
+				"serial_number":      "JAD2345678X",
+				"access_policy_uuid": testAccessPolicyUUID,
+				"admin_password":     "Secret123!",
+			}
+			if tt.licenses != nil {
+				raw["licenses"] = tt.licenses
+			}
+			mockApply(t, resourceFTDDevice(), nil, raw, config)
+
+			if got := mock.Body("POST", ftdDeviceType.OnboardPath)["licenses"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("onboarding licenses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFTDDeviceSerialNumberChange(t *testing.T) {
+	tests := []struct {
+		name               string
+		preventReplacement bool
+		allowReplacement   bool
+		wantErr            bool
+	}{
+		{name: "replaces the device"},
+		{name: "blocked by prevent_device_replacement", preventReplacement: true, wantErr: true},
+		{name: "acknowledged with allow_replacement", preventReplacement: true, allowReplacement: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, config := newMockCDO(t)
+			handleFTDOnboarding(mock, config)
+			r := resourceFTDDevice()
+
+			raw := map[string]interface{}{
+				"name":               "ftd-1",
+				"serial_number":      "JAD2345678X",
+				"access_policy_uuid": testAccessPolicyUUID,
+				"admin_password":     "Secret123!",
+				"allow_replacement":  tt.allowReplacement,
+			}
+			state := mockApply(t, r, nil, raw, config)
+
+			config.PreventDeviceReplacement = tt.preventReplacement
+			raw["serial_number"] = "JAD9876543Y"
+			diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), config)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "allow_replacement") {
+					t.Fatalf("Diff() error = %v, want the replacement blocked", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Diff() error = %s", err)
+			}
+			if !diff.RequiresNew() || !diff.Attributes["serial_number"].RequiresNew {
+				t.Errorf("diff = %v, want serial_number to replace the device", diff)
+			}
+		})
+	}
+}
+
+func TestFTDDeviceRegistrationKeyPlansCleanlyAfterRegistration(t *testing.T) {
+	mock, config := newMockCDO(t)
+	mock.Handle("POST", registrationKeyFTDDeviceType.OnboardPath, func(m *mockCDO, body map[string]interface{}) (int, interface{}) {
+		m.objects["/inventory/devices/ftds/"+testFTDDeviceUID] = map[string]interface{}{
+			"uid":                testFTDDeviceUID,
+			"name":               body["name"],
+			"fmcAccessPolicyUid": body["fmcAccessPolicyUid"],
+		}
+		return http.StatusOK, map[string]interface{}{
+			"entityUid":             testFTDDeviceUID,
+			"cliCommand":            "configure manager add cdo.example.com key nat",
+			"transactionPollingUrl": config.apiURL("/transactions/tx-1"),
+		}
+	})
+	mock.Handle("GET", "/transactions/tx-1", func(*mockCDO, map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"cdoTransactionStatus": "DONE"}
+	})
+	r := resourceFTDDevice()
+
+	raw := map[string]interface{}{
+		"name":               "ftd-1",
+		"onboarding_method":  onboardingMethodRegistrationKey,
+		"access_policy_uuid": testAccessPolicyUUID,
+	}
+	state := mockApply(t, r, nil, raw, config)
+
+	// The device runs cli_command, registers and reports its serial number.
+	mock.Object("/inventory/devices/ftds/" + testFTDDeviceUID)["serial"] = "JAD2345678X"
+	state, diags := r.RefreshWithoutUpgrade(context.Background(), state, config)
+	if diags.HasError() {
+		t.Fatalf("Refresh() error = %v", diags)
+	}
+	if got := state.Attributes["serial_number"]; got != "JAD2345678X" {
+		t.Fatalf("serial_number = %q, want JAD2345678X", got)
+	}
+
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), config)
+	if err != nil {
+		t.Fatalf("Diff() error = %s", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("diff = %v, want no changes after registration", diff)
+	}
+}
@@ -0,0 +1,551 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// ftdBatchDeviceType onboards any number of cdFMC managed FTDs by serial
+// number in a single transaction. Devices are deleted one by one like any
+// other cdFMC managed FTD.
+var ftdBatchDeviceType = DeviceType{
+	Name:            "FTD device",
+	OnboardPath:     "/inventory/devices/ftds/ztp/bulk",
+	DeletePath:      "/inventory/devices/ftds/cdfmcManaged/%s/delete",
+	DeleteMethod:    "POST",
+	Licenses:        ftdLicenses,
+	DefaultLicenses: []string{"BASE"},
+}
+
+// cdfmcFTDDeviceType is the inventory device type of cdFMC managed FTDs.
+const cdfmcFTDDeviceType = "FTDC"
+
+// batchConcurrency is how many devices of a batch are updated or deleted at
+// once.
+const batchConcurrency = 10
+
+// resourceFTDDevicesBatch onboards many FTDs with one bulk ZTP transaction
+// instead of one transaction per device. Devices added to the batch later are
+// onboarded together on the next apply, and removed devices are deleted.
+func resourceFTDDevicesBatch() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceFTDDevicesBatchCreate,
+		ReadContext:   resourceFTDDevicesBatchRead,
+		UpdateContext: resourceFTDDevicesBatchUpdate,
+		DeleteContext: resourceFTDDevicesBatchDelete,
+
+		CustomizeDiff: resourceFTDDevicesBatchCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"device": {
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateDeviceName,
+						},
+						"serial_number": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateSerialNumber,
+						},
+					},
+				},
+				Description: "Devices to onboard, identified by serial number.",
+			},
+			"access_policy_uuid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsUUID,
+				Description:  "Access policy assigned to the devices of the batch. Defaults to the provider's default_access_policy_uuid. Changing it reassigns every device of the batch.",
+			},
+			"licenses": licensesSchema(ftdBatchDeviceType.Licenses, ftdBatchDeviceType.DefaultLicenses),
+			"admin_password": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        true,
+				ValidateFunc:     validateAdminPassword,
+				DiffSuppressFunc: suppressAfterCreate,
+				Description:      "Admin password set on the devices during onboarding. It is write-only: it is never kept in state and is read from the configuration on each apply that onboards devices, so it must stay configured for devices added to the batch later to get it.",
+			},
+			"device_uids": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "UIDs of the onboarded devices, by serial number.",
+			},
+			"failed_devices": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Why onboarding failed, by serial number. Failed devices are retried on the next apply.",
+			},
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(60 * time.Minute),
+		},
+	}
+}
+
+// batchDevice is a device of a batch as configured.
+type batchDevice struct {
+	Name         string
+	SerialNumber string
+}
+
+func resourceFTDDevicesBatchCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	uids, failed, err := onboardFTDBatch(ctx, d, config, batchDevices(d), schema.TimeoutCreate)
+	if len(uids) > 0 {
+		d.SetId(id.UniqueId())
+	}
+	d.Set("device_uids", uids)
+	d.Set("failed_devices", failed)
+	if len(uids) == 0 {
+		if err == nil {
+			err = fmt.Errorf("none of the devices were onboarded: %s", failedSummary(failed))
+		}
+		return diag.FromErr(err)
+	}
+	return batchFailureWarning(failed)
+}
+
+func resourceFTDDevicesBatchRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	// A single listing of the cdFMC managed FTDs, rather than a request per
+	// device, keeps refreshes of large batches cheap.
+	devices, err := listDevices(ctx, config, inventoryQuery("deviceType", cdfmcFTDDeviceType))
+	if err != nil {
+		return diag.Errorf("Error listing FTD devices: %s", err)
+	}
+	existing := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		existing[string(device.Uid)] = true
+	}
+
+	uids := stringMap(d.Get("device_uids").(map[string]interface{}))
+	for serial, uid := range uids {
+		if !existing[uid] {
+			// Deleted outside of Terraform, it is onboarded again on the
+			// next apply.
+			delete(uids, serial)
+		}
+	}
+
+	d.Set("device_uids", uids)
+	return nil
+}
+
+func resourceFTDDevicesBatchUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	uids := stringMap(d.Get("device_uids").(map[string]interface{}))
+	configured := map[string]batchDevice{}
+	for _, device := range batchDevices(d) {
+		configured[device.SerialNumber] = device
+	}
+
+	var removed []string
+	for serial, uid := range uids {
+		if _, ok := configured[serial]; !ok {
+			removed = append(removed, uid)
+		}
+	}
+	if err := deleteFTDBatch(ctx, config, removed, transactionWait(config, d, schema.TimeoutUpdate)); err != nil {
+		return diag.FromErr(err)
+	}
+	for serial := range uids {
+		if _, ok := configured[serial]; !ok {
+			delete(uids, serial)
+		}
+	}
+	d.Set("device_uids", uids)
+
+	// Devices that are already onboarded are updated in place, missing ones
+	// get the new settings when they are onboarded below.
+	updates, err := ftdBatchUpdates(d, config, uids, configured)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := updateFTDBatch(ctx, config, updates, transactionWait(config, d, schema.TimeoutUpdate)); err != nil {
+		d.Partial(true)
+		return diag.FromErr(err)
+	}
+
+	var missing []batchDevice
+	for serial, device := range configured {
+		if _, ok := uids[serial]; !ok {
+			missing = append(missing, device)
+		}
+	}
+	if len(missing) == 0 {
+		d.Set("failed_devices", map[string]string{})
+		return nil
+	}
+
+	onboarded, failed, err := onboardFTDBatch(ctx, d, config, missing, schema.TimeoutUpdate)
+	for serial, uid := range onboarded {
+		uids[serial] = uid
+	}
+	d.Set("device_uids", uids)
+	d.Set("failed_devices", failed)
+	if len(onboarded) == 0 && err != nil {
+		return diag.FromErr(err)
+	}
+	return batchFailureWarning(failed)
+}
+
+func resourceFTDDevicesBatchDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var uids []string
+	for _, uid := range stringMap(d.Get("device_uids").(map[string]interface{})) {
+		uids = append(uids, uid)
+	}
+	if err := deleteFTDBatch(ctx, config, uids, deleteWait(d)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceFTDDevicesBatchCustomizeDiff rejects devices sharing a serial
+// number, and plans an update whenever a configured device hasn't been
+// onboarded, because it failed or was deleted outside of Terraform, so that it
+// is retried.
+func resourceFTDDevicesBatchCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !d.NewValueKnown("device") {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for _, v := range d.Get("device").(*schema.Set).List() {
+		serial := v.(map[string]interface{})["serial_number"].(string)
+		if seen[serial] {
+			return fmt.Errorf("serial number %s is used by more than one device of the batch", serial)
+		}
+		seen[serial] = true
+	}
+	if d.Id() == "" {
+		return nil
+	}
+
+	uids := d.Get("device_uids").(map[string]interface{})
+	for serial := range seen {
+		if _, ok := uids[serial]; !ok {
+			if err := d.SetNewComputed("device_uids"); err != nil {
+				return err
+			}
+			return d.SetNewComputed("failed_devices")
+		}
+	}
+	return nil
+}
+
+// onboardFTDBatch onboards devices with a single bulk request and returns the
+// UIDs of the devices that were onboarded, and why the others weren't, by
+// serial number. The error is that of the bulk transaction.
+func onboardFTDBatch(ctx context.Context, d *schema.ResourceData, config *ProviderConfig, devices []batchDevice, timeoutKey string) (map[string]string, map[string]string, error) {
+	accessPolicyUUID, err := batchAccessPolicyUUID(d, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	licenses := deviceLicenses(d, ftdBatchDeviceType)
+	d.Set("licenses", licenses)
+
+	// The password is blanked in state once the batch exists, so it is taken
+	// from the configuration.
+	var adminPassword string
+	if raw := d.GetRawConfig(); !raw.IsNull() {
+		if v := raw.GetAttr("admin_password"); v.IsKnown() && !v.IsNull() {
+			adminPassword = v.AsString()
+		}
+	}
+	d.Set("admin_password", "")
+
+	entries := make([]map[string]interface{}, 0, len(devices))
+	for _, device := range devices {
+		entry := map[string]interface{}{
+			"name":               device.Name,
+			"serialNumber":       device.SerialNumber,
+			"fmcAccessPolicyUid": accessPolicyUUID,
+			"licenses":           licenses,
+		}
+		if adminPassword != "" {
+			entry["adminPassword"] = adminPassword
+		}
+		entries = append(entries, entry)
+	}
+
+	_, err = onboardDevice(ctx, config, ftdBatchDeviceType, map[string]interface{}{"devices": entries}, transactionWait(config, d, timeoutKey))
+	var txErr *transactionError
+	if err != nil && !errors.As(err, &txErr) {
+		// The request itself failed, so no device was onboarded.
+		failed := map[string]string{}
+		for _, device := range devices {
+			failed[device.SerialNumber] = err.Error()
+		}
+		return map[string]string{}, failed, err
+	}
+
+	serials := make([]string, 0, len(devices))
+	for _, device := range devices {
+		serials = append(serials, device.SerialNumber)
+	}
+	uids, lookupErr := findFTDDeviceUIDsBySerial(ctx, config, serials)
+	if lookupErr != nil {
+		return nil, nil, fmt.Errorf("Error listing onboarded FTD devices: %s", lookupErr)
+	}
+
+	failed := map[string]string{}
+	for _, serial := range serials {
+		if _, ok := uids[serial]; ok {
+			continue
+		}
+		failed[serial] = "device not found in the inventory after onboarding"
+		if detail := lastTransactionError(err); detail != "" {
+			failed[serial] = detail
+		}
+	}
+	return uids, failed, err
+}
+
+// findFTDDeviceUIDsBySerial returns the UIDs of the cdFMC managed FTDs with
+// the given serial numbers. Serials that are still missing are looked up again
+// until the inventory has indexed them or deviceIndexTimeout passes.
+func findFTDDeviceUIDsBySerial(ctx context.Context, config *ProviderConfig, serials []string) (map[string]string, error) {
+	uids := map[string]string{}
+	deadline := time.Now().Add(deviceIndexTimeout)
+	for {
+		devices, err := listDevices(ctx, config, inventoryQuery("deviceType", cdfmcFTDDeviceType))
+		if err != nil {
+			return nil, err
+		}
+		for _, device := range devices {
+			if containsString(serials, device.SerialNumber) {
+				uids[device.SerialNumber] = string(device.Uid)
+			}
+		}
+
+		if len(uids) == len(serials) || time.Now().After(deadline) {
+			return uids, nil
+		}
+		if err := sleepContext(ctx, deviceIndexInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// batchAccessPolicyUUID returns the access policy of the devices of a batch.
+func batchAccessPolicyUUID(d *schema.ResourceData, config *ProviderConfig) (string, error) {
+	accessPolicyUUID := d.Get("access_policy_uuid").(string)
+	if accessPolicyUUID == "" {
+		accessPolicyUUID = config.DefaultAccessPolicyUUID
+	}
+	if accessPolicyUUID == "" {
+		return "", fmt.Errorf("access_policy_uuid must be set on the resource or as the provider's default_access_policy_uuid")
+	}
+	return accessPolicyUUID, nil
+}
+
+// ftdBatchUpdates returns the changes to apply to the onboarded devices of a
+// batch, by device UID: a new name, and the batch's access policy and
+// licenses when they changed.
+func ftdBatchUpdates(d *schema.ResourceData, config *ProviderConfig, uids map[string]string, configured map[string]batchDevice) (map[string]map[string]interface{}, error) {
+	shared := map[string]interface{}{}
+	if d.HasChange("access_policy_uuid") {
+		accessPolicyUUID, err := batchAccessPolicyUUID(d, config)
+		if err != nil {
+			return nil, err
+		}
+		shared["fmcAccessPolicyUid"] = accessPolicyUUID
+	}
+	if d.HasChange("licenses") {
+		shared["licenses"] = deviceLicenses(d, ftdBatchDeviceType)
+	}
+
+	old, _ := d.GetChange("device")
+	oldNames := map[string]string{}
+	for _, v := range old.(*schema.Set).List() {
+		device := v.(map[string]interface{})
+		oldNames[device["serial_number"].(string)] = device["name"].(string)
+	}
+
+	updates := map[string]map[string]interface{}{}
+	for serial, uid := range uids {
+		payload := map[string]interface{}{}
+		for k, v := range shared {
+			payload[k] = v
+		}
+		if name := configured[serial].Name; name != oldNames[serial] {
+			payload["name"] = name
+		}
+		if len(payload) > 0 {
+			updates[uid] = payload
+		}
+	}
+	return updates, nil
+}
+
+// updateFTDBatch applies updates, by device UID, a few devices at a time.
+func updateFTDBatch(ctx context.Context, config *ProviderConfig, updates map[string]map[string]interface{}, opts waitOptions) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []string
+	)
+	slots := make(chan struct{}, batchConcurrency)
+	for uid, payload := range updates {
+		wg.Add(1)
+		go func(uid string, payload map[string]interface{}) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if err := updateFTDBatchDevice(ctx, config, uid, payload, opts); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %s", uid, err))
+				mu.Unlock()
+			}
+		}(uid, payload)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("Error updating FTD devices: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// deleteFTDBatch deletes the devices with the given UIDs, a few at a time.
+func deleteFTDBatch(ctx context.Context, config *ProviderConfig, uids []string, opts waitOptions) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []string
+	)
+	slots := make(chan struct{}, batchConcurrency)
+	for _, uid := range uids {
+		wg.Add(1)
+		go func(uid string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			err := deleteDevice(ctx, config, ftdBatchDeviceType, uid, opts)
+			if err == nil {
+				err = waitForFTDDeviceDeleted(ctx, config, uid)
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %s", uid, err))
+				mu.Unlock()
+			}
+		}(uid)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("Error deleting FTD devices: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func updateFTDBatchDevice(ctx context.Context, config *ProviderConfig, uid string, payload map[string]interface{}, opts waitOptions) error {
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"PATCH",
+		config.apiURL(fmt.Sprintf("/inventory/devices/ftds/%s", uid)),
+		payload,
+	)
+	if err != nil {
+		return err
+	}
+
+	var transaction TransactionResponse
+	if err := decodeJSON(resp, &transaction); err != nil {
+		return fmt.Errorf("Error parsing response: %s", err)
+	}
+	if transaction.TransactionPollingURL != "" {
+		return waitFor(ctx, config, transaction.TransactionPollingURL, opts)
+	}
+	return nil
+}
+
+func batchDevices(d *schema.ResourceData) []batchDevice {
+	var devices []batchDevice
+	for _, v := range d.Get("device").(*schema.Set).List() {
+		device := v.(map[string]interface{})
+		devices = append(devices, batchDevice{
+			Name:         device["name"].(string),
+			SerialNumber: device["serial_number"].(string),
+		})
+	}
+	return devices
+}
+
+// batchFailureWarning reports the devices of a batch that couldn't be
+// onboarded, without failing the devices that were.
+func batchFailureWarning(failed map[string]string) diag.Diagnostics {
+	if len(failed) == 0 {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("%d FTD device(s) of the batch were not onboarded", len(failed)),
+		Detail:   fmt.Sprintf("%s. They are retried on the next apply.", failedSummary(failed)),
+	}}
+}
+
+func failedSummary(failed map[string]string) string {
+	parts := make([]string, 0, len(failed))
+	for serial, reason := range failed {
+		parts = append(parts, fmt.Sprintf("%s: %s", serial, reason))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "; ")
+}
+
+func stringMap(m map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = v.(string)
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const testOtherAccessPolicyUUID = "00000000-0000-0000-0000-0000000000b2"
+
+// handleFTDBatchOnboarding makes bulk onboarding store every device of the
+// request in the inventory, where the batch looks them up by serial number.
+func handleFTDBatchOnboarding(mock *mockCDO, config *ProviderConfig) {
+	mock.Handle("POST", ftdBatchDeviceType.OnboardPath, func(m *mockCDO, body map[string]interface{}) (int, interface{}) {
+		for _, v := range body["devices"].([]interface{}) {
+			device := v.(map[string]interface{})
+			serial := device["serialNumber"].(string)
+			m.objects["/inventory/devices/ftds/"+serial] = map[string]interface{}{
+				"uid":                serial,
+				"name":               device["name"],
+				"serial":             serial,
+				"deviceType":         cdfmcFTDDeviceType,
+				"fmcAccessPolicyUid": device["fmcAccessPolicyUid"],
+				"licenses":           device["licenses"],
+			}
+		}
+		return http.StatusOK, map[string]interface{}{
+			"transactionPollingUrl": config.apiURL("/transactions/tx-1"),
+		}
+	})
+	mock.Handle("GET", "/transactions/tx-1", func(*mockCDO, map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"cdoTransactionStatus": "DONE"}
+	})
+	mock.Handle("GET", "/inventory/devices", func(m *mockCDO, _ map[string]interface{}) (int, interface{}) {
+		var items []interface{}
+		for path, object := range m.objects {
+			if strings.HasPrefix(path, "/inventory/devices/ftds/") {
+				items = append(items, object)
+			}
+		}
+		return http.StatusOK, map[string]interface{}{"count": len(items), "items": items}
+	})
+}
+
+func testFTDBatchConfig(accessPolicyUUID string, licenses ...string) map[string]interface{} {
+	raw := map[string]interface{}{
+		"device": []interface{}{
+			map[string]interface{}{"name": "ftd-1", "serial_number": "JAD2345678X"},
+			map[string]interface{}{"name": "ftd-2", "serial_number": "JAD9876543Y"},
+		},
+		"access_policy_uuid": accessPolicyUUID,
+		"admin_password":     "Secret123!",
+	}
+	if len(licenses) > 0 {
+		var list []interface{}
+		for _, license := range licenses {
+			list = append(list, license)
+		}
+		raw["licenses"] = list
+	}
+	return raw
+}
+
+func TestFTDDevicesBatchUpdatesEveryDevice(t *testing.T) {
+	mock, config := newMockCDO(t)
+	handleFTDBatchOnboarding(mock, config)
+	r := resourceFTDDevicesBatch()
+
+	state := mockApply(t, r, nil, testFTDBatchConfig(testAccessPolicyUUID), config)
+
+	raw := testFTDBatchConfig(testOtherAccessPolicyUUID, "BASE", "THREAT")
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), config)
+	if err != nil {
+		t.Fatalf("Diff() error = %s", err)
+	}
+	if diff.RequiresNew() {
+		t.Fatalf("changing the batch's policy and licenses plans a replacement: %v", diff)
+	}
+
+	mockApply(t, r, state, raw, config)
+	for _, serial := range []string{"JAD2345678X", "JAD9876543Y"} {
+		device := mock.Object("/inventory/devices/ftds/" + serial)
+		if got := device["fmcAccessPolicyUid"]; got != testOtherAccessPolicyUUID {
+			t.Errorf("device %s access policy = %v, want %s", serial, got, testOtherAccessPolicyUUID)
+		}
+		if got, _ := device["licenses"].([]interface{}); len(got) != 2 || got[0] != "BASE" || got[1] != "THREAT" {
+			t.Errorf("device %s licenses = %v, want [BASE THREAT]", serial, device["licenses"])
+		}
+	}
+
+	var onboardings int
+	for _, request := range mock.Requests() {
+		if request == "POST "+ftdBatchDeviceType.OnboardPath {
+			onboardings++
+		}
+	}
+	if onboardings != 1 {
+		t.Errorf("batch onboarded %d times, want once", onboardings)
+	}
+}
+
+func TestFTDDevicesBatchRejectsDuplicateSerials(t *testing.T) {
+	_, config := newMockCDO(t)
+	r := resourceFTDDevicesBatch()
+
+	raw := testFTDBatchConfig(testAccessPolicyUUID)
+	raw["device"] = []interface{}{
+		map[string]interface{}{"name": "ftd-1", "serial_number": "JAD2345678X"},
+		map[string]interface{}{"name": "ftd-2", "serial_number": "JAD2345678X"},
+	}
+	_, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(raw), config)
+	if err == nil || !strings.Contains(err.Error(), "JAD2345678X") {
+		t.Errorf("Diff() error = %v, want the duplicate serial number rejected", err)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+var iosDeviceType = DeviceType{
+	Name:         "IOS device",
+	OnboardPath:  "/inventory/devices/ios",
+	DeletePath:   "/inventory/devices/ios/%s",
+	DeleteMethod: "DELETE",
+}
+
+var iosDevice = sdcDeviceKind{
+	DeviceType:                   iosDeviceType,
+	Resource:                     "cdo_ios_device",
+	Path:                         "/inventory/devices/ios/%s",
+	DefaultPort:                  22,
+	HostDescription:              "Hostname or IP address the device is reached at over SSH.",
+	IgnoreCertificateDescription: "Onboard the device even when its SSH host key can't be verified.",
+}
+
+// resourceIOSDevice onboards a Cisco IOS or IOS-XE device through a Secure
+// Device Connector, which logs in to it over SSH with the given credentials.
+func resourceIOSDevice() *schema.Resource {
+	return resourceSDCDevice(iosDevice)
+}
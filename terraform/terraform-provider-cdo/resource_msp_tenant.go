@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// MSPTenant is a tenant managed from an MSP portal.
+type MSPTenant struct {
+	Uid         flexString `json:"uid"`
+	Name        string     `json:"name"`
+	DisplayName string     `json:"displayName"`
+	Region      string     `json:"region"`
+}
+
+// resourceMSPTenant creates a customer tenant under the MSP portal the
+// provider's credentials belong to. Destroying it removes the tenant from the
+// portal.
+func resourceMSPTenant() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceMSPTenantCreate,
+		ReadContext:   resourceMSPTenantRead,
+		DeleteContext: resourceMSPTenantDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringLenBetween(1, 64),
+				Description:  "Unique name of the tenant.",
+			},
+			"display_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "Name of the tenant shown in the CDO UI. Defaults to name.",
+			},
+			"region": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(15 * time.Minute),
+		},
+	}
+}
+
+func resourceMSPTenantCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	release, err := config.acquireTransactionSlot(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
+
+	payload := map[string]interface{}{
+		"tenantName": d.Get("name").(string),
+	}
+	if v, ok := d.GetOk("display_name"); ok {
+		payload["displayName"] = v.(string)
+	}
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		config.apiURL("/msp/tenants/create"),
+		payload,
+	)
+	if err != nil {
+		return diag.Errorf("Error creating MSP tenant: %s", err)
+	}
+
+	var transaction TransactionResponse
+	if err := decodeJSON(resp, &transaction); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	if err := waitFor(ctx, config, transaction.TransactionPollingURL, transactionWait(config, d, schema.TimeoutCreate)); err != nil {
+		return diag.FromErr(err)
+	}
+	if transaction.EntityUid == "" {
+		return diag.Errorf("Error creating MSP tenant: the response didn't include the tenant UID")
+	}
+
+	d.SetId(string(transaction.EntityUid))
+	return resourceMSPTenantRead(ctx, d, m)
+}
+
+func resourceMSPTenantRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/msp/tenants/%s", d.Id())),
+		nil,
+	)
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] MSP tenant %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading MSP tenant: %s", err)
+	}
+
+	var tenant MSPTenant
+	if err := decodeJSON(resp, &tenant); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	d.Set("name", tenant.Name)
+	d.Set("display_name", tenant.DisplayName)
+	d.Set("region", tenant.Region)
+	return nil
+}
+
+func resourceMSPTenantDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	release, err := config.acquireTransactionSlot(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
+
+	_, err = makeRequest(
+		ctx,
+		config,
+		"DELETE",
+		config.apiURL(fmt.Sprintf("/msp/tenants/%s", d.Id())),
+		nil,
+	)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return diag.Errorf("Error removing MSP tenant: %s", err)
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMSPTenantDeleteWaitsForTransactionSlot(t *testing.T) {
+	mock, config := newMockCDO(t)
+	config.transactionSlots = make(chan struct{}, 1)
+	config.transactionSlots <- struct{}{}
+	r := resourceMSPTenant()
+
+	d := r.TestResourceData()
+	d.SetId("00000000-0000-0000-0000-000000000042")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if diags := r.DeleteContext(ctx, d, config); !diags.HasError() {
+		t.Fatal("Delete() succeeded while every transaction slot was taken")
+	}
+	if requests := mock.Requests(); len(requests) > 0 {
+		t.Errorf("requests = %v, want none before a slot is free", requests)
+	}
+}
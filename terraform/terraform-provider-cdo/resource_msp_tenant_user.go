@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// resourceMSPTenantUser adds a user to a tenant of the MSP portal. API-only
+// users get a token scoped to the tenant, so that pipelines can manage it
+// without access to the portal.
+func resourceMSPTenantUser() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceMSPTenantUserCreate,
+		ReadContext:   resourceMSPTenantUserRead,
+		DeleteContext: resourceMSPTenantUserDelete,
+
+		Schema: map[string]*schema.Schema{
+			"tenant_uid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "UID of the tenant, e.g. the id of a cdo_msp_tenant.",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Username, the email address of the user or the name of an API-only user.",
+			},
+			"role": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(userRoles, false),
+				Description:  fmt.Sprintf("Role of the user in the tenant, one of %s.", strings.Join(userRoles, ", ")),
+			},
+			"api_only_user": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  false,
+			},
+			"api_token": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "API token of an API-only user, scoped to the tenant.",
+			},
+		},
+	}
+}
+
+func resourceMSPTenantUserCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	tenantUID := d.Get("tenant_uid").(string)
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		config.apiURL(fmt.Sprintf("/msp/tenants/%s/users", tenantUID)),
+		[]map[string]interface{}{{
+			"username":    d.Get("name").(string),
+			"role":        rolePrefix + d.Get("role").(string),
+			"apiOnlyUser": d.Get("api_only_user").(bool),
+		}},
+	)
+	if err != nil {
+		return diag.Errorf("Error adding user to MSP tenant: %s", err)
+	}
+
+	var users []User
+	if err := decodeJSON(resp, &users); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+	if len(users) != 1 {
+		return diag.Errorf("Error adding user to MSP tenant: expected 1 user in the response, got %d", len(users))
+	}
+	d.SetId(string(users[0].Uid))
+
+	if d.Get("api_only_user").(bool) {
+		resp, err := makeRequest(
+			ctx,
+			config,
+			"POST",
+			config.apiURL(fmt.Sprintf("/msp/tenants/%s/users/%s/api-token", tenantUID, d.Id())),
+			nil,
+		)
+		if err != nil {
+			return diag.Errorf("Error generating API token: %s", err)
+		}
+
+		var token apiTokenResponse
+		if err := decodeJSON(resp, &token); err != nil {
+			return diag.Errorf("Error parsing response: %s", err)
+		}
+		d.Set("api_token", token.APIToken)
+	}
+
+	return resourceMSPTenantUserRead(ctx, d, m)
+}
+
+func resourceMSPTenantUserRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/msp/tenants/%s/users/%s", d.Get("tenant_uid").(string), d.Id())),
+		nil,
+	)
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] User %s of MSP tenant not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading MSP tenant user: %s", err)
+	}
+
+	var user User
+	if err := decodeJSON(resp, &user); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	d.Set("name", user.Name)
+	d.Set("role", strings.TrimPrefix(user.Role, rolePrefix))
+	d.Set("api_only_user", user.ApiOnlyUser)
+	return nil
+}
+
+func resourceMSPTenantUserDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	_, err := makeRequest(
+		ctx,
+		config,
+		"DELETE",
+		config.apiURL(fmt.Sprintf("/msp/tenants/%s/users/%s", d.Get("tenant_uid").(string), d.Id())),
+		nil,
+	)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return diag.Errorf("Error removing user from MSP tenant: %s", err)
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// networkObjectKind describes one kind of cdFMC network object: its type in
+// the API and the collection it lives in.
+type networkObjectKind struct {
+	Type string
+	Path string
+}
+
+var networkObjectKinds = map[string]networkObjectKind{
+	"host":    {Type: "Host", Path: "/object/hosts"},
+	"range":   {Type: "Range", Path: "/object/ranges"},
+	"network": {Type: "Network", Path: "/object/networks"},
+	"fqdn":    {Type: "FQDN", Path: "/object/fqdns"},
+}
+
+// NetworkObject is a cdFMC host, range, network or FQDN object.
+type NetworkObject struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Value       string `json:"value"`
+	Description string `json:"description"`
+}
+
+// resourceNetworkObject manages a network object in the cloud-delivered FMC,
+// for use in access rules and network object groups.
+func resourceNetworkObject() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceNetworkObjectCreate,
+		ReadContext:   resourceNetworkObjectRead,
+		UpdateContext: resourceNetworkObjectUpdate,
+		DeleteContext: resourceNetworkObjectDelete,
+
+		CustomizeDiff: func(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+			if !d.NewValueKnown("value") {
+				return nil
+			}
+			return validateNetworkObjectValue(d.Get("type").(string), d.Get("value").(string))
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{"host", "range", "network", "fqdn"}, false),
+				Description:  "Kind of object: host, range, network or fqdn.",
+			},
+			"value": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Address of the object: an IP address for a host, first-last IP addresses for a range, a CIDR block for a network or a domain name for an FQDN.",
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"fmc_type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Type of the object in the cdFMC API, used to reference it from a cdo_network_object_group.",
+			},
+		},
+	}
+}
+
+func resourceNetworkObjectCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	kind := networkObjectKinds[d.Get("type").(string)]
+	var object NetworkObject
+	if err := createCDFMCObject(ctx, config, kind.Path, networkObjectFrom(d, kind), &object); err != nil {
+		return diag.Errorf("Error creating network object: %s", err)
+	}
+
+	d.SetId(object.ID)
+	return resourceNetworkObjectRead(ctx, d, m)
+}
+
+func resourceNetworkObjectRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	kind := networkObjectKinds[d.Get("type").(string)]
+	var object NetworkObject
+	err := getCDFMCObject(ctx, config, kind.Path, d.Id(), &object)
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] Network object %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading network object: %s", err)
+	}
+
+	d.Set("name", object.Name)
+	d.Set("value", object.Value)
+	d.Set("description", object.Description)
+	d.Set("fmc_type", object.Type)
+	return nil
+}
+
+func resourceNetworkObjectUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	kind := networkObjectKinds[d.Get("type").(string)]
+	object := networkObjectFrom(d, kind)
+	object.ID = d.Id()
+	if err := updateCDFMCObject(ctx, config, kind.Path, d.Id(), object); err != nil {
+		return diag.Errorf("Error updating network object: %s", err)
+	}
+	return resourceNetworkObjectRead(ctx, d, m)
+}
+
+func resourceNetworkObjectDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	kind := networkObjectKinds[d.Get("type").(string)]
+	if err := deleteCDFMCObject(ctx, config, kind.Path, d.Id()); err != nil {
+		return diag.Errorf("Error deleting network object: %s", err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func networkObjectFrom(d *schema.ResourceData, kind networkObjectKind) NetworkObject {
+	return NetworkObject{
+		Name:        d.Get("name").(string),
+		Type:        kind.Type,
+		Value:       d.Get("value").(string),
+		Description: d.Get("description").(string),
+	}
+}
+
+// validateNetworkObjectValue checks that value is an address of the given
+// kind of network object.
+func validateNetworkObjectValue(kind, value string) error {
+	switch kind {
+	case "host":
+		if net.ParseIP(value) == nil {
+			return fmt.Errorf("value %q of a host object must be an IP address", value)
+		}
+	case "range":
+		first, last, ok := strings.Cut(value, "-")
+		if !ok || net.ParseIP(first) == nil || net.ParseIP(last) == nil {
+			return fmt.Errorf("value %q of a range object must be two IP addresses separated by a dash", value)
+		}
+	case "network":
+		if _, _, err := net.ParseCIDR(value); err != nil {
+			return fmt.Errorf("value %q of a network object must be a CIDR block", value)
+		}
+	}
+	return nil
+}
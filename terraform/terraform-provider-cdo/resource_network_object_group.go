@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const networkGroupPath = "/object/networkgroups"
+
+// NetworkGroup is a cdFMC network object group, made of references to other
+// network objects and of literal addresses.
+type NetworkGroup struct {
+	ID          string           `json:"id,omitempty"`
+	Name        string           `json:"name"`
+	Type        string           `json:"type"`
+	Description string           `json:"description"`
+	Objects     []cdfmcObjectRef `json:"objects,omitempty"`
+	Literals    []networkLiteral `json:"literals,omitempty"`
+}
+
+type cdfmcObjectRef struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+func resourceNetworkObjectGroup() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceNetworkObjectGroupCreate,
+		ReadContext:   resourceNetworkObjectGroupRead,
+		UpdateContext: resourceNetworkObjectGroupUpdate,
+		DeleteContext: resourceNetworkObjectGroupDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"objects": {
+				Type:         schema.TypeSet,
+				Optional:     true,
+				AtLeastOneOf: []string{"objects", "literals"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"type": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice([]string{"Host", "Range", "Network", "FQDN", "NetworkGroup"}, false),
+							Description:  "cdFMC type of the object, e.g. the fmc_type of a cdo_network_object.",
+						},
+					},
+				},
+				Description: "Network objects and groups in the group.",
+			},
+			"literals": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.Any(validation.IsIPAddress, validation.IsCIDR),
+				},
+				Description: "IP addresses and CIDR blocks in the group.",
+			},
+		},
+	}
+}
+
+func resourceNetworkObjectGroupCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var group NetworkGroup
+	if err := createCDFMCObject(ctx, config, networkGroupPath, networkGroupFrom(d), &group); err != nil {
+		return diag.Errorf("Error creating network object group: %s", err)
+	}
+
+	d.SetId(group.ID)
+	return resourceNetworkObjectGroupRead(ctx, d, m)
+}
+
+func resourceNetworkObjectGroupRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	var group NetworkGroup
+	err := getCDFMCObject(ctx, config, networkGroupPath, d.Id(), &group)
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] Network object group %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading network object group: %s", err)
+	}
+
+	objects := make([]interface{}, 0, len(group.Objects))
+	for _, object := range group.Objects {
+		objects = append(objects, map[string]interface{}{
+			"id":   object.ID,
+			"type": object.Type,
+		})
+	}
+	literals := make([]string, 0, len(group.Literals))
+	for _, literal := range group.Literals {
+		literals = append(literals, literal.Value)
+	}
+
+	d.Set("name", group.Name)
+	d.Set("description", group.Description)
+	d.Set("objects", objects)
+	d.Set("literals", literals)
+	return nil
+}
+
+func resourceNetworkObjectGroupUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	group := networkGroupFrom(d)
+	group.ID = d.Id()
+	if err := updateCDFMCObject(ctx, config, networkGroupPath, d.Id(), group); err != nil {
+		return diag.Errorf("Error updating network object group: %s", err)
+	}
+	return resourceNetworkObjectGroupRead(ctx, d, m)
+}
+
+func resourceNetworkObjectGroupDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := deleteCDFMCObject(ctx, config, networkGroupPath, d.Id()); err != nil {
+		return diag.Errorf("Error deleting network object group: %s", err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func networkGroupFrom(d *schema.ResourceData) NetworkGroup {
+	group := NetworkGroup{
+		Name:        d.Get("name").(string),
+		Type:        "NetworkGroup",
+		Description: d.Get("description").(string),
+	}
+	for _, v := range d.Get("objects").(*schema.Set).List() {
+		object := v.(map[string]interface{})
+		group.Objects = append(group.Objects, cdfmcObjectRef{
+			ID:   object["id"].(string),
+			Type: object["type"].(string),
+		})
+	}
+	for _, v := range d.Get("literals").(*schema.Set).List() {
+		literal := networkLiteral{Type: "Host", Value: v.(string)}
+		if strings.Contains(literal.Value, "/") {
+			literal.Type = "Network"
+		}
+		group.Literals = append(group.Literals, literal)
+	}
+	return group
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// notificationEvents are the tenant events a subscription can be notified of.
+var notificationEvents = []string{
+	"DEVICE_DISCONNECTED",
+	"DEVICE_ONBOARDING_FAILED",
+	"DEPLOYMENT_COMPLETED",
+	"DEPLOYMENT_FAILED",
+	"CONFLICT_DETECTED",
+	"HA_FAILOVER",
+	"LICENSE_EXPIRING",
+}
+
+// notificationIntegrations are the kinds of webhook a subscription posts to,
+// each getting the payload format the service expects.
+var notificationIntegrations = []string{"SLACK", "PAGERDUTY", "WEBEX", "CUSTOM"}
+
+// NotificationSubscription sends the tenant events it subscribes to to a
+// webhook.
+type NotificationSubscription struct {
+	Uid             flexString `json:"uid,omitempty"`
+	Name            string     `json:"name"`
+	IntegrationType string     `json:"integrationType"`
+	WebhookURL      string     `json:"webhookUrl"`
+	Events          []string   `json:"events"`
+	Enabled         bool       `json:"enabled"`
+}
+
+// resourceNotificationSubscription manages a tenant notification rule and the
+// webhook, e.g. a Slack channel or a PagerDuty service, it notifies.
+func resourceNotificationSubscription() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceNotificationSubscriptionCreate,
+		ReadContext:   resourceNotificationSubscriptionRead,
+		UpdateContext: resourceNotificationSubscriptionUpdate,
+		DeleteContext: resourceNotificationSubscriptionDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"integration_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(notificationIntegrations, false),
+				Description:  fmt.Sprintf("Kind of webhook notified, one of %s.", strings.Join(notificationIntegrations, ", ")),
+			},
+			"webhook_url": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Sensitive:    true,
+				ValidateFunc: validation.IsURLWithHTTPS,
+				Description:  "URL the notifications are posted to. It is sensitive as it usually embeds the credentials of the integration.",
+			},
+			"events": {
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(notificationEvents, false),
+				},
+				Description: fmt.Sprintf("Events to notify of, any of %s.", strings.Join(notificationEvents, ", ")),
+			},
+			"enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+		},
+	}
+}
+
+func resourceNotificationSubscriptionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		config.apiURL("/notifications/subscriptions"),
+		notificationSubscriptionFrom(d),
+	)
+	if err != nil {
+		return diag.Errorf("Error creating notification subscription: %s", err)
+	}
+
+	var subscription NotificationSubscription
+	if err := decodeJSON(resp, &subscription); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	d.SetId(string(subscription.Uid))
+	return resourceNotificationSubscriptionRead(ctx, d, m)
+}
+
+func resourceNotificationSubscriptionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/notifications/subscriptions/%s", d.Id())),
+		nil,
+	)
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] Notification subscription %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading notification subscription: %s", err)
+	}
+
+	var subscription NotificationSubscription
+	if err := decodeJSON(resp, &subscription); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	d.Set("name", subscription.Name)
+	d.Set("integration_type", subscription.IntegrationType)
+	// The API masks the webhook URL once it is saved, keep the configured
+	// one unless it was never known, e.g. after an import.
+	if _, ok := d.GetOk("webhook_url"); !ok {
+		d.Set("webhook_url", subscription.WebhookURL)
+	}
+	d.Set("events", subscription.Events)
+	d.Set("enabled", subscription.Enabled)
+	return nil
+}
+
+func resourceNotificationSubscriptionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	_, err := makeRequest(
+		ctx,
+		config,
+		"PUT",
+		config.apiURL(fmt.Sprintf("/notifications/subscriptions/%s", d.Id())),
+		notificationSubscriptionFrom(d),
+	)
+	if err != nil {
+		return diag.Errorf("Error updating notification subscription: %s", err)
+	}
+	return resourceNotificationSubscriptionRead(ctx, d, m)
+}
+
+func resourceNotificationSubscriptionDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	_, err := makeRequest(
+		ctx,
+		config,
+		"DELETE",
+		config.apiURL(fmt.Sprintf("/notifications/subscriptions/%s", d.Id())),
+		nil,
+	)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return diag.Errorf("Error deleting notification subscription: %s", err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func notificationSubscriptionFrom(d *schema.ResourceData) NotificationSubscription {
+	return NotificationSubscription{
+		Name:            d.Get("name").(string),
+		IntegrationType: d.Get("integration_type").(string),
+		WebhookURL:      d.Get("webhook_url").(string),
+		Events:          stringSet(d.Get("events").(*schema.Set)),
+		Enabled:         d.Get("enabled").(bool),
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const policyAssignmentPath = "/assignment/policyassignments"
+
+// policyAssignment lists the devices a cdFMC policy is assigned to. Its ID is
+// the ID of the policy.
+type policyAssignment struct {
+	ID      string           `json:"id,omitempty"`
+	Type    string           `json:"type"`
+	Policy  cdfmcObjectRef   `json:"policy"`
+	Targets []cdfmcObjectRef `json:"targets"`
+}
+
+// resourcePolicyAssignment moves an onboarded FTD to another access policy in
+// place, through the cdFMC policy assignment API, and deploys the change.
+// Destroying it leaves the device on its current policy, as a device always
+// has one. Devices managed with cdo_ftd_device can be moved by changing their
+// access_policy_uuid instead; when this resource is used for one, that
+// attribute should be added to the device's ignore_changes.
+func resourcePolicyAssignment() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourcePolicyAssignmentCreate,
+		ReadContext:   resourcePolicyAssignmentRead,
+		UpdateContext: resourcePolicyAssignmentUpdate,
+		DeleteContext: resourcePolicyAssignmentDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: resourcePolicyAssignmentImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"device_uid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "CDO UID of the FTD, e.g. the id of a cdo_ftd_device.",
+			},
+			"access_policy_uuid": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.IsUUID,
+				Description:  "Access policy the device is assigned to.",
+			},
+			"deploy": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Deploy the device after assigning the policy, so that it enforces it. When false, the assignment stays pending until the next deployment.",
+			},
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Update: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+func resourcePolicyAssignmentCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := applyPolicyAssignment(ctx, d, config, schema.TimeoutCreate); err != nil {
+		return errorDiagnostics("Error assigning access policy", err)
+	}
+
+	d.SetId(d.Get("device_uid").(string))
+	return resourcePolicyAssignmentRead(ctx, d, m)
+}
+
+func resourcePolicyAssignmentRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	device, err := getFTDDevice(ctx, config, d.Id())
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] FTD device %s not found, removing its policy assignment from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading FTD device: %s", err)
+	}
+
+	d.Set("device_uid", d.Id())
+	d.Set("access_policy_uuid", device.AccessPolicyUid)
+	return nil
+}
+
+func resourcePolicyAssignmentUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if !d.HasChange("access_policy_uuid") {
+		return nil
+	}
+
+	if err := applyPolicyAssignment(ctx, d, config, schema.TimeoutUpdate); err != nil {
+		return errorDiagnostics("Error assigning access policy", err)
+	}
+	return resourcePolicyAssignmentRead(ctx, d, m)
+}
+
+func resourcePolicyAssignmentDelete(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// A device can't be left without an access policy, so it keeps the one
+	// it is on.
+	d.SetId("")
+	return nil
+}
+
+func resourcePolicyAssignmentImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	d.Set("deploy", true)
+	return []*schema.ResourceData{d}, nil
+}
+
+// applyPolicyAssignment assigns d's access policy to its device and, unless
+// deploy is false, deploys the device and waits for the deployment.
+func applyPolicyAssignment(ctx context.Context, d *schema.ResourceData, config *ProviderConfig, timeoutKey string) error {
+	uid := d.Get("device_uid").(string)
+	device, err := getFTDDevice(ctx, config, uid)
+	if err != nil {
+		return fmt.Errorf("Error reading FTD device: %w", err)
+	}
+	if device.UidOnFmc == "" {
+		return fmt.Errorf("FTD device %s isn't registered with the cloud-delivered FMC yet", uid)
+	}
+
+	if err := assignAccessPolicy(ctx, config, d.Get("access_policy_uuid").(string), device.UidOnFmc); err != nil {
+		return err
+	}
+
+	if d.Get("deploy").(bool) {
+		return deployDevice(ctx, config, uid, transactionWait(config, d, timeoutKey))
+	}
+	return nil
+}
+
+// assignAccessPolicy assigns an access policy to a cdFMC device, keeping the
+// other devices the policy is already assigned to.
+func assignAccessPolicy(ctx context.Context, config *ProviderConfig, policyUID, fmcDeviceUID string) error {
+	target := cdfmcObjectRef{ID: fmcDeviceUID, Type: "Device"}
+
+	var assignment policyAssignment
+	err := getCDFMCObject(ctx, config, policyAssignmentPath, policyUID, &assignment)
+	if errors.Is(err, errNotFound) {
+		assignment = policyAssignment{
+			Type:    "PolicyAssignment",
+			Policy:  cdfmcObjectRef{ID: policyUID, Type: "AccessPolicy"},
+			Targets: []cdfmcObjectRef{target},
+		}
+		if err := createCDFMCObject(ctx, config, policyAssignmentPath, assignment, &assignment); err != nil {
+			return fmt.Errorf("Error creating policy assignment: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Error reading policy assignment: %w", err)
+	}
+
+	for _, t := range assignment.Targets {
+		if t.ID == fmcDeviceUID {
+			return nil
+		}
+	}
+	assignment.Targets = append(assignment.Targets, target)
+	if err := updateCDFMCObject(ctx, config, policyAssignmentPath, policyUID, assignment); err != nil {
+		return fmt.Errorf("Error updating policy assignment: %w", err)
+	}
+	return nil
+}
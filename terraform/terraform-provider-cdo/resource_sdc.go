@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type SDC struct {
+	Uid              flexString `json:"uid"`
+	Name             string     `json:"name"`
+	BootstrapData    string     `json:"bootstrapData"`
+	BootstrapURL     string     `json:"bootstrapUrl"`
+	Status           string     `json:"status"`
+	SoftwareVersion  string     `json:"softwareVersion"`
+	DefaultConnector bool       `json:"defaultConnector"`
+}
+
+func resourceSDC() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSDCCreate,
+		ReadContext:   resourceSDCRead,
+		DeleteContext: resourceSDCDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"bootstrap_data": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Enrollment data used to bootstrap the connector VM.",
+			},
+			"bootstrap_url": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "URL the connector VM downloads its bootstrap configuration from.",
+			},
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+	}
+}
+
+func resourceSDCCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	release, err := config.acquireTransactionSlot(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
+
+	payload := map[string]interface{}{
+		"name": d.Get("name").(string),
+	}
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		config.apiURL("/connectors"),
+		payload,
+	)
+	if err != nil {
+		return diag.Errorf("Error creating SDC: %s", err)
+	}
+
+	var transaction TransactionResponse
+	if err := decodeJSON(resp, &transaction); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	if err := waitFor(ctx, config, transaction.TransactionPollingURL, transactionWait(config, d, schema.TimeoutCreate)); err != nil {
+		d.SetId(string(transaction.EntityUid))
+		return diag.FromErr(err)
+	}
+
+	d.SetId(string(transaction.EntityUid))
+	return resourceSDCRead(ctx, d, m)
+}
+
+func resourceSDCRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/connectors/%s", d.Id())),
+		nil,
+	)
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] SDC %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading SDC: %s", err)
+	}
+
+	var sdc SDC
+	if err := decodeJSON(resp, &sdc); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+
+	d.Set("name", sdc.Name)
+	d.Set("bootstrap_data", sdc.BootstrapData)
+	d.Set("bootstrap_url", sdc.BootstrapURL)
+	return nil
+}
+
+func resourceSDCDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	release, err := config.acquireTransactionSlot(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
+
+	resp, err := makeAPIRequest(
+		ctx,
+		config,
+		"DELETE",
+		config.apiURL(fmt.Sprintf("/connectors/%s", d.Id())),
+		nil,
+	)
+	if err != nil {
+		return diag.Errorf("Error deleting SDC: %s", err)
+	}
+
+	transaction, err := deleteTransaction(resp)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if transaction != nil {
+		if err := waitFor(ctx, config, transaction.TransactionPollingURL, deleteWait(d)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
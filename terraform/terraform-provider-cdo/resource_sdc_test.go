@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSDCReadRemovesDeletedSDC(t *testing.T) {
+	_, config := newMockCDO(t)
+	r := resourceSDC()
+
+	d := r.TestResourceData()
+	d.SetId("00000000-0000-0000-0000-000000000042")
+	if diags := r.ReadContext(context.Background(), d, config); diags.HasError() {
+		t.Fatalf("Read() error = %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("Id() = %q, want the SDC removed from state", d.Id())
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const userPageSize = 200
+
+// userRoles are the tenant roles a user can be given. The API prefixes them
+// with rolePrefix.
+var userRoles = []string{"ADMIN", "SUPER_ADMIN", "READ_ONLY", "DEPLOY_ONLY"}
+
+const rolePrefix = "ROLE_"
+
+type User struct {
+	Uid         flexString `json:"uid"`
+	Name        string     `json:"name"`
+	Role        string     `json:"role"`
+	ApiOnlyUser bool       `json:"apiOnlyUser"`
+}
+
+type userPage struct {
+	Count flexInt `json:"count"`
+	Items []User  `json:"items"`
+}
+
+// resourceUser manages a user of the CDO tenant and the role they are given.
+func resourceUser() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceUserCreate,
+		ReadContext:   resourceUserRead,
+		UpdateContext: resourceUserUpdate,
+		DeleteContext: resourceUserDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Username, the email address of the user or the name of an API-only user.",
+			},
+			"role": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(userRoles, false),
+				Description:  fmt.Sprintf("Role of the user in the tenant, one of %s.", strings.Join(userRoles, ", ")),
+			},
+			"api_only_user": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     false,
+				Description: "Create a user that can only use the API, e.g. for automation.",
+			},
+		},
+	}
+}
+
+func resourceUserCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	user, err := createUser(ctx, config, d.Get("name").(string), d.Get("role").(string), d.Get("api_only_user").(bool))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(string(user.Uid))
+	return resourceUserRead(ctx, d, m)
+}
+
+func resourceUserRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	user, err := getUser(ctx, config, d.Id())
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] User %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading user: %s", err)
+	}
+
+	d.Set("name", user.Name)
+	d.Set("role", strings.TrimPrefix(user.Role, rolePrefix))
+	d.Set("api_only_user", user.ApiOnlyUser)
+	return nil
+}
+
+func resourceUserUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if !d.HasChange("role") {
+		return nil
+	}
+
+	if err := updateUserRole(ctx, config, d.Id(), d.Get("role").(string)); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceUserRead(ctx, d, m)
+}
+
+func resourceUserDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := deleteUser(ctx, config, d.Id(), transactionWait(config, d, schema.TimeoutDelete)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func createUser(ctx context.Context, config *ProviderConfig, name, role string, apiOnly bool) (*User, error) {
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"POST",
+		config.apiURL("/users"),
+		map[string]interface{}{
+			"name":        name,
+			"role":        rolePrefix + role,
+			"apiOnlyUser": apiOnly,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating user: %s", err)
+	}
+
+	var user User
+	if err := decodeJSON(resp, &user); err != nil {
+		return nil, fmt.Errorf("Error parsing response: %s", err)
+	}
+	return &user, nil
+}
+
+func updateUserRole(ctx context.Context, config *ProviderConfig, uid, role string) error {
+	_, err := makeRequest(
+		ctx,
+		config,
+		"PATCH",
+		config.apiURL(fmt.Sprintf("/users/%s", uid)),
+		map[string]interface{}{
+			"role": rolePrefix + role,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("Error updating user: %s", err)
+	}
+	return nil
+}
+
+// deleteUser deletes the user with the given UID and waits for the delete
+// transaction, if the API started one. A user that is already gone isn't an
+// error.
+func deleteUser(ctx context.Context, config *ProviderConfig, uid string, opts waitOptions) error {
+	release, err := config.acquireTransactionSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	resp, err := makeAPIRequest(
+		ctx,
+		config,
+		"DELETE",
+		config.apiURL(fmt.Sprintf("/users/%s", uid)),
+		nil,
+	)
+	if errors.Is(err, errNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Error deleting user: %s", err)
+	}
+
+	transaction, err := deleteTransaction(resp)
+	if err != nil {
+		return err
+	}
+	if transaction != nil {
+		return waitFor(ctx, config, transaction.TransactionPollingURL, opts)
+	}
+	return nil
+}
+
+func getUser(ctx context.Context, config *ProviderConfig, uid string) (*User, error) {
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf("/users/%s", uid)),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := decodeJSON(resp, &user); err != nil {
+		return nil, fmt.Errorf("Error parsing response: %s", err)
+	}
+	return &user, nil
+}
+
+// listUsers returns every user of the tenant, following the pagination.
+func listUsers(ctx context.Context, config *ProviderConfig) ([]User, error) {
+	var users []User
+
+	for offset := 0; ; offset += userPageSize {
+		params := url.Values{}
+		params.Set("limit", fmt.Sprint(userPageSize))
+		params.Set("offset", fmt.Sprint(offset))
+
+		resp, err := makeRequest(
+			ctx,
+			config,
+			"GET",
+			config.apiURL(fmt.Sprintf("/users?%s", params.Encode())),
+			nil,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		var page userPage
+		if err := decodeJSON(resp, &page); err != nil {
+			return nil, fmt.Errorf("Error parsing response: %s", err)
+		}
+
+		users = append(users, page.Items...)
+		if len(page.Items) == 0 || len(users) >= int(page.Count) {
+			return users, nil
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUserLifecycle(t *testing.T) {
+	mock, config := newMockCDO(t)
+	r := resourceUser()
+
+	state := mockApply(t, r, nil, map[string]interface{}{
+		"name": "jane@example.com",
+		"role": "READ_ONLY",
+	}, config)
+	path := "/users/" + state.ID
+	if got := mock.Object(path)["role"]; got != "ROLE_READ_ONLY" {
+		t.Fatalf("user role = %v, want ROLE_READ_ONLY", got)
+	}
+
+	state = mockApply(t, r, state, map[string]interface{}{
+		"name": "jane@example.com",
+		"role": "ADMIN",
+	}, config)
+	if got := state.Attributes["role"]; got != "ADMIN" {
+		t.Errorf("role = %q, want ADMIN", got)
+	}
+	if got := mock.Object(path)["role"]; got != "ROLE_ADMIN" {
+		t.Errorf("user role = %v, want ROLE_ADMIN", got)
+	}
+
+	mockDestroy(t, r, state, config)
+	if mock.Object(path) != nil {
+		t.Errorf("user %s still exists after destroy", state.ID)
+	}
+
+	sent := map[string]bool{}
+	for _, request := range mock.Requests() {
+		sent[request] = true
+	}
+	for _, want := range []string{
+		"POST /users",
+		"PATCH " + path,
+		"DELETE " + path,
+	} {
+		if !sent[want] {
+			t.Errorf("no %s request sent, got %v", want, mock.Requests())
+		}
+	}
+}
+
+func TestUserReadRemovesDeletedUser(t *testing.T) {
+	_, config := newMockCDO(t)
+	r := resourceUser()
+
+	d := r.TestResourceData()
+	d.SetId("00000000-0000-0000-0000-000000000042")
+	if diags := r.ReadContext(context.Background(), d, config); diags.HasError() {
+		t.Fatalf("Read() error = %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("Id() = %q, want the user removed from state", d.Id())
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// sdcDeviceKind describes a kind of device CDO onboards through a Secure
+// Device Connector, which logs in to the device with the given credentials.
+// The resources of all such kinds share their schema and CRUD functions.
+type sdcDeviceKind struct {
+	DeviceType DeviceType
+	// Resource is the resource type name, e.g. "cdo_asa_device".
+	Resource string
+	// Path is the device endpoint relative to the API root, with a %s
+	// placeholder for the device UID.
+	Path        string
+	DefaultPort int
+	// HostDescription and IgnoreCertificateDescription document how the
+	// connector reaches the device.
+	HostDescription              string
+	IgnoreCertificateDescription string
+}
+
+type SDCDevice struct {
+	Uid               flexString `json:"uid"`
+	Name              string     `json:"name"`
+	ConnectivityState string     `json:"connectivityState"`
+}
+
+func resourceSDCDevice(kind sdcDeviceKind) *schema.Resource {
+	return &schema.Resource{
+		CreateContext: kind.create,
+		ReadContext:   kind.read,
+		UpdateContext: kind.update,
+		DeleteContext: kind.delete,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateDeviceName,
+			},
+			"host": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: kind.HostDescription,
+			},
+			"port": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      kind.DefaultPort,
+				ValidateFunc: validation.IsPortNumber,
+			},
+			"username": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"password": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				Sensitive:        true,
+				DiffSuppressFunc: suppressAfterCreate,
+				Description:      "Password CDO logs in to the device with. It is only sent on create and is not kept in state, so later changes to it are ignored.",
+			},
+			"sdc_uid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsUUID,
+				ExactlyOneOf: []string{"sdc_uid", "sdc_name"},
+				Description:  "UID of the Secure Device Connector that reaches the device, e.g. the uid of a cdo_sdc data source.",
+			},
+			"sdc_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Name of the Secure Device Connector that reaches the device, as an alternative to sdc_uid.",
+			},
+			"ignore_certificate": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Description: kind.IgnoreCertificateDescription,
+			},
+			"connectivity_state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+	}
+}
+
+func (kind sdcDeviceKind) create(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	err := kind.onboard(ctx, d, config)
+	notifyOnboardingResult(ctx, config, OnboardingSummary{
+		Resource:  kind.Resource,
+		Name:      d.Get("name").(string),
+		DeviceUID: d.Id(),
+	}, err)
+	if err != nil {
+		return errorDiagnostics(fmt.Sprintf("Error creating %s", kind.DeviceType.Name), err)
+	}
+
+	return kind.read(ctx, d, m)
+}
+
+// onboard onboards the device and waits for it to come online, within the
+// create timeout as a whole.
+func (kind sdcDeviceKind) onboard(ctx context.Context, d *schema.ResourceData, config *ProviderConfig) error {
+	start := time.Now()
+	timeout := pollTimeout(config, d, schema.TimeoutCreate)
+
+	payload := map[string]interface{}{
+		"name":              d.Get("name").(string),
+		"deviceAddress":     net.JoinHostPort(d.Get("host").(string), strconv.Itoa(d.Get("port").(int))),
+		"username":          d.Get("username").(string),
+		"password":          d.Get("password").(string),
+		"ignoreCertificate": d.Get("ignore_certificate").(bool),
+	}
+	if v, ok := d.GetOk("sdc_uid"); ok {
+		payload["connectorUid"] = v.(string)
+	} else {
+		payload["connectorName"] = d.Get("sdc_name").(string)
+	}
+	// The password is only needed for onboarding, keep it out of state.
+	d.Set("password", "")
+
+	onboarding, err := onboardDevice(ctx, config, kind.DeviceType, payload, transactionWait(config, d, schema.TimeoutCreate))
+	if onboarding != nil && onboarding.EntityUid != "" {
+		d.SetId(string(onboarding.EntityUid))
+	}
+	if err != nil {
+		return err
+	}
+
+	return waitForOnline(ctx, config, kind.DeviceType, d.Id(), timeout-time.Since(start), func() (string, error) {
+		device, err := kind.get(ctx, config, d.Id())
+		if err != nil {
+			return "", err
+		}
+		return device.ConnectivityState, nil
+	})
+}
+
+func (kind sdcDeviceKind) read(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	device, err := kind.get(ctx, config, d.Id())
+	if errors.Is(err, errNotFound) {
+		log.Printf("[WARN] %s %s not found, removing from state", kind.DeviceType.Name, d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("Error reading %s: %s", kind.DeviceType.Name, err)
+	}
+
+	d.Set("name", device.Name)
+	d.Set("connectivity_state", device.ConnectivityState)
+	return nil
+}
+
+// update renames the device, the only setting that can change in place.
+func (kind sdcDeviceKind) update(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if !d.HasChange("name") {
+		return kind.read(ctx, d, m)
+	}
+
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"PATCH",
+		config.apiURL(fmt.Sprintf(kind.Path, d.Id())),
+		map[string]interface{}{"name": d.Get("name").(string)},
+	)
+	if err != nil {
+		return errorDiagnostics(fmt.Sprintf("Error updating %s", kind.DeviceType.Name), err)
+	}
+
+	var transaction TransactionResponse
+	if err := decodeJSON(resp, &transaction); err != nil {
+		return diag.Errorf("Error parsing response: %s", err)
+	}
+	if transaction.TransactionPollingURL != "" {
+		if err := waitFor(ctx, config, transaction.TransactionPollingURL, transactionWait(config, d, schema.TimeoutUpdate)); err != nil {
+			return errorDiagnostics(fmt.Sprintf("Error updating %s", kind.DeviceType.Name), err)
+		}
+	}
+
+	return kind.read(ctx, d, m)
+}
+
+func (kind sdcDeviceKind) delete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfig)
+
+	if err := config.CheckWritable(); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := deleteDevice(ctx, config, kind.DeviceType, d.Id(), deleteWait(d)); err != nil {
+		return errorDiagnostics(fmt.Sprintf("Error deleting %s", kind.DeviceType.Name), err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func (kind sdcDeviceKind) get(ctx context.Context, config *ProviderConfig, uid string) (*SDCDevice, error) {
+	resp, err := makeRequest(
+		ctx,
+		config,
+		"GET",
+		config.apiURL(fmt.Sprintf(kind.Path, uid)),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var device SDCDevice
+	if err := decodeJSON(resp, &device); err != nil {
+		return nil, fmt.Errorf("Error parsing response: %s", err)
+	}
+	return &device, nil
+}
+
+// waitForOnline polls the connectivity state of a device onboarded through a
+// connector until it is online, tolerating the 404s returned until the
+// inventory has indexed the device.
+func waitForOnline(ctx context.Context, config *ProviderConfig, deviceType DeviceType, uid string, timeout time.Duration, connectivityState func() (string, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		state, err := connectivityState()
+		if err != nil && !errors.Is(err, errNotFound) {
+			return fmt.Errorf("Error reading onboarded %s: %s", deviceType.Name, err)
+		}
+		if err == nil && state == connectivityStateOnline {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s %s did not come online within %s", deviceType.Name, uid, timeout)
+		}
+		if err := sleepContext(ctx, config.PollInterval); err != nil {
+			return err
+		}
+	}
+}
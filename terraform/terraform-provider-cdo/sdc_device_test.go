@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const (
+	testSDCDeviceUID = "00000000-0000-0000-0000-0000000000a1"
+	testSDCUID       = "00000000-0000-0000-0000-0000000000c1"
+)
+
+// handleSDCDeviceOnboarding makes the onboarding of a device of the given
+// kind store it in state connectivityState, with a transaction that is done
+// once pending has passed.
+func handleSDCDeviceOnboarding(mock *mockCDO, config *ProviderConfig, kind sdcDeviceKind, connectivityState string, pending time.Duration) {
+	var onboarded time.Time
+	mock.Handle("POST", kind.DeviceType.OnboardPath, func(m *mockCDO, body map[string]interface{}) (int, interface{}) {
+		onboarded = time.Now()
+		m.objects[fmt.Sprintf(kind.Path, testSDCDeviceUID)] = map[string]interface{}{
+			"uid":               testSDCDeviceUID,
+			"name":              body["name"],
+			"connectivityState": connectivityState,
+		}
+		return http.StatusOK, map[string]interface{}{
+			"entityUid":             testSDCDeviceUID,
+			"transactionPollingUrl": config.apiURL("/transactions/tx-1"),
+		}
+	})
+	mock.Handle("GET", "/transactions/tx-1", func(*mockCDO, map[string]interface{}) (int, interface{}) {
+		if time.Since(onboarded) < pending {
+			return http.StatusOK, map[string]interface{}{"cdoTransactionStatus": "PENDING"}
+		}
+		return http.StatusOK, map[string]interface{}{"cdoTransactionStatus": "DONE"}
+	})
+}
+
+var testSDCDeviceKinds = []sdcDeviceKind{asaDevice, iosDevice}
+
+func TestSDCDeviceRenameUpdatesInPlace(t *testing.T) {
+	for _, kind := range testSDCDeviceKinds {
+		t.Run(kind.Resource, func(t *testing.T) {
+			mock, config := newMockCDO(t)
+			handleSDCDeviceOnboarding(mock, config, kind, connectivityStateOnline, 0)
+			r := resourceSDCDevice(kind)
+
+			raw := map[string]interface{}{
+				"name":     "device-1",
+				"host":     "192.0.2.1",
+				"username": "admin",
+				"password": "Secret123!",
+				"sdc_name": "sdc-1",
+			}
+			state := mockApply(t, r, nil, raw, config)
+
+			raw["name"] = "device-renamed"
+			diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), config)
+			if err != nil {
+				t.Fatalf("Diff() error = %s", err)
+			}
+			if diff.RequiresNew() {
+				t.Fatalf("renaming plans a replacement: %v", diff)
+			}
+
+			updated := mockApply(t, r, state, raw, config)
+			if updated.ID != state.ID {
+				t.Errorf("ID = %q after rename, want it unchanged as %q", updated.ID, state.ID)
+			}
+			if got := updated.Attributes["name"]; got != "device-renamed" {
+				t.Errorf("name = %q, want device-renamed", got)
+			}
+			if got := mock.Object(fmt.Sprintf(kind.Path, testSDCDeviceUID))["name"]; got != "device-renamed" {
+				t.Errorf("device name = %v, want device-renamed", got)
+			}
+
+			var onboardings int
+			for _, request := range mock.Requests() {
+				if request == "POST "+kind.DeviceType.OnboardPath {
+					onboardings++
+				}
+				if strings.HasPrefix(request, "DELETE ") {
+					t.Errorf("rename sent %s, want no delete", request)
+				}
+			}
+			if onboardings != 1 {
+				t.Errorf("device onboarded %d times, want once", onboardings)
+			}
+		})
+	}
+}
+
+func TestSDCDeviceCreateTimeoutCoversOnlineWait(t *testing.T) {
+	for _, kind := range testSDCDeviceKinds {
+		t.Run(kind.Resource, func(t *testing.T) {
+			mock, config := newMockCDO(t)
+			config.PollTimeout = 400 * time.Millisecond
+			handleSDCDeviceOnboarding(mock, config, kind, "UNREACHABLE", 300*time.Millisecond)
+			r := resourceSDCDevice(kind)
+
+			d := r.TestResourceData()
+			d.Set("name", "device-1")
+			d.Set("host", "192.0.2.1")
+			d.Set("sdc_name", "sdc-1")
+
+			start := time.Now()
+			diags := r.CreateContext(context.Background(), d, config)
+			if !diags.HasError() {
+				t.Fatal("Create() succeeded, want the device not coming online to fail it")
+			}
+			// The online wait only gets what the transaction left of the
+			// timeout, rather than a whole timeout of its own.
+			if elapsed := time.Since(start); elapsed >= 600*time.Millisecond {
+				t.Errorf("Create() took %s, want it within the %s timeout", elapsed, config.PollTimeout)
+			}
+		})
+	}
+}
+
+func TestSDCDeviceOnboardsThroughSDCUID(t *testing.T) {
+	for _, kind := range testSDCDeviceKinds {
+		t.Run(kind.Resource, func(t *testing.T) {
+			mock, config := newMockCDO(t)
+			handleSDCDeviceOnboarding(mock, config, kind, connectivityStateOnline, 0)
+
+			mockApply(t, resourceSDCDevice(kind), nil, map[string]interface{}{
+				"name":     "device-1",
+				"host":     "192.0.2.1",
+				"username": "admin",
+				"password": "Secret123!",
+				"sdc_uid":  testSDCUID,
+			}, config)
+
+			body := mock.Body("POST", kind.DeviceType.OnboardPath)
+			if got := body["connectorUid"]; got != testSDCUID {
+				t.Errorf("connectorUid = %v, want %s", got, testSDCUID)
+			}
+			if _, ok := body["connectorName"]; ok {
+				t.Errorf("connectorName = %v, want it unset", body["connectorName"])
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type TransactionResponse struct {
+	TransactionUid        flexString             `json:"transactionUid"`
+	TransactionType       string                 `json:"transactionType"`
+	TransactionPollingURL string                 `json:"transactionPollingUrl"`
+	CDOTransactionStatus  string                 `json:"cdoTransactionStatus"`
+	EntityUid             flexString             `json:"entityUid"`
+	ErrorMessage          string                 `json:"errorMessage"`
+	ErrorDetails          map[string]interface{} `json:"errorDetails"`
+	SubmissionTime        string                 `json:"submissionTime"`
+	LastUpdatedTime       string                 `json:"lastUpdatedTime"`
+	LastActiveTime        string                 `json:"lastActiveTime"`
+	// Stage is the step a running transaction is at, e.g. CLAIMED,
+	// PROVISIONING or REGISTERING while a device is onboarded. Not every
+	// transaction type reports one.
+	Stage string `json:"stage"`
+}
+
+// stage is the most specific progress the transaction reports: its stage
+// when it has one, or else its status.
+func (t TransactionResponse) stage() string {
+	if t.Stage != "" {
+		return t.Stage
+	}
+	return t.CDOTransactionStatus
+}
+
+// States reported by resources for the last transaction they waited for.
+const (
+	transactionStatePending = "PENDING"
+	transactionStateDone    = "DONE"
+	transactionStateError   = "ERROR"
+	transactionStateTimeout = "TIMEOUT"
+)
+
+// transactionState maps the outcome of waitFor to a transaction state.
+// Errors that tell nothing about the transaction itself, such as a lost
+// connection, leave it pending.
+func transactionState(err error) string {
+	var txErr *transactionError
+	switch {
+	case err == nil:
+		return transactionStateDone
+	case errors.As(err, &txErr) && txErr.TimedOut:
+		return transactionStateTimeout
+	case errors.As(err, &txErr):
+		return transactionStateError
+	default:
+		return transactionStatePending
+	}
+}
+
+const (
+	defaultPollInterval    = 10 * time.Second
+	defaultPollMaxAttempts = 30
+
+	// Delete transactions usually finish much faster than onboarding, so
+	// they are checked more often, for as long as the delete timeout allows.
+	deletePollInterval = 3 * time.Second
+)
+
+// waitOptions tells waitFor which transaction statuses end the wait and how
+// often to poll. Statuses in none of the sets are treated as unrecognized;
+// zero Interval and MaxAttempts fall back to the defaults. When MaxInterval is
+// greater than Interval, the delay doubles after every check up to
+// MaxInterval, with some jitter so that parallel waits don't poll in step.
+type waitOptions struct {
+	SuccessStatuses []string
+	FailureStatuses []string
+	PendingStatuses []string
+	Interval        time.Duration
+	MaxInterval     time.Duration
+	MaxAttempts     int
+}
+
+// cdoTransactionWait matches the statuses reported by CDO transactions such
+// as device onboarding and deletion.
+var cdoTransactionWait = waitOptions{
+	SuccessStatuses: []string{"DONE"},
+	FailureStatuses: []string{"ERROR", "CANCELLED"},
+	PendingStatuses: []string{"PENDING", "IN_PROGRESS", "CANCELLING"},
+}
+
+// transactionWait returns the options for waiting on a transaction with the
+// provider's poll intervals, bounded by the provider's poll timeout or the
+// resource's timeout for the operation, whichever is shorter.
+func transactionWait(config *ProviderConfig, d *schema.ResourceData, timeoutKey string) waitOptions {
+	opts := cdoTransactionWait
+	opts.Interval = config.PollInterval
+	opts.MaxInterval = config.PollMaxInterval
+	opts.MaxAttempts = pollAttempts(config.PollInterval, config.PollMaxInterval, pollTimeout(config, d, timeoutKey))
+	return opts
+}
+
+// pollTimeout is the resource's timeout for the operation, or the provider's
+// poll_timeout_seconds when it is set and shorter.
+func pollTimeout(config *ProviderConfig, d *schema.ResourceData, timeoutKey string) time.Duration {
+	timeout := d.Timeout(timeoutKey)
+	if config.PollTimeout > 0 && config.PollTimeout < timeout {
+		timeout = config.PollTimeout
+	}
+	return timeout
+}
+
+// pollAttempts returns how many checks, starting interval apart and backing
+// off up to maxInterval, fit in timeout.
+func pollAttempts(interval, maxInterval, timeout time.Duration) int {
+	if interval <= 0 {
+		return 0
+	}
+	attempts := 0
+	for elapsed := time.Duration(0); elapsed+interval <= timeout; attempts++ {
+		elapsed += interval
+		interval = nextPollInterval(interval, maxInterval)
+	}
+	if attempts > 0 {
+		return attempts
+	}
+	return 1
+}
+
+// nextPollInterval doubles interval, capped at maxInterval. A maxInterval not
+// greater than interval keeps polling at a fixed interval.
+func nextPollInterval(interval, maxInterval time.Duration) time.Duration {
+	if maxInterval <= interval {
+		return interval
+	}
+	if interval *= 2; interval > maxInterval {
+		return maxInterval
+	}
+	return interval
+}
+
+// pollJitter spreads d by up to 10% either way.
+func pollJitter(d time.Duration) time.Duration {
+	spread := int64(d / 10)
+	return d - time.Duration(spread) + time.Duration(rand.Int63n(2*spread+1))
+}
+
+// withResourcePolling applies a resource's poll_interval_seconds,
+// poll_max_interval_seconds and poll_max_attempts overrides to opts.
+func withResourcePolling(opts waitOptions, d *schema.ResourceData) waitOptions {
+	if v, ok := d.GetOk("poll_interval_seconds"); ok {
+		opts.Interval = time.Duration(v.(int)) * time.Second
+	}
+	if v, ok := d.GetOk("poll_max_interval_seconds"); ok {
+		opts.MaxInterval = time.Duration(v.(int)) * time.Second
+	}
+	if v, ok := d.GetOk("poll_max_attempts"); ok {
+		opts.MaxAttempts = v.(int)
+	}
+	return opts
+}
+
+// deleteWait returns the options for waiting on a delete transaction, using
+// the shorter delete interval and spreading the attempts over the resource's
+// delete timeout.
+func deleteWait(d *schema.ResourceData) waitOptions {
+	opts := cdoTransactionWait
+	opts.Interval = deletePollInterval
+	opts.MaxAttempts = int(d.Timeout(schema.TimeoutDelete) / deletePollInterval)
+	return opts
+}
+
+// waitFor polls pollingURL until the transaction reaches one of the terminal
+// statuses in opts.
+func waitFor(ctx context.Context, config *ProviderConfig, pollingURL string, opts waitOptions) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultPollMaxAttempts
+	}
+	backoff := opts.MaxInterval > interval
+	start := time.Now()
+	var last TransactionResponse
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		resp, err := makeRequest(ctx, config, "GET", pollingURL, nil)
+		if isTemporary(err) && ctx.Err() == nil && attempt+1 < maxAttempts {
+			// The transaction keeps running on the CDO side, so a poll that
+			// failed even after the client's retries only costs an attempt.
+			logAPIEvent(ctx, config, "WARN", "Polling transaction failed, continuing to poll", map[string]interface{}{
+				"url":     pollingURL,
+				"error":   err.Error(),
+				"attempt": attempt + 1,
+			})
+			if err := sleepContext(ctx, interval); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var transaction TransactionResponse
+		if err := decodeJSON(resp, &transaction); err != nil {
+			return fmt.Errorf("Error parsing polling response: %s", err)
+		}
+
+		status := transaction.CDOTransactionStatus
+		logAPIEvent(ctx, config, "DEBUG", "Polled transaction", map[string]interface{}{
+			"method":             "GET",
+			"url":                pollingURL,
+			"transaction_status": status,
+			"attempt":            attempt + 1,
+			"elapsed_ms":         time.Since(start).Milliseconds(),
+		})
+
+		// Onboarding can take half an hour, so each step it reaches is logged
+		// where it shows up without TF_LOG=DEBUG.
+		if stage := transaction.stage(); stage != last.stage() {
+			logAPIEvent(ctx, config, "INFO", "Transaction reached a new stage", map[string]interface{}{
+				"url":              pollingURL,
+				"transaction_type": transaction.TransactionType,
+				"stage":            stage,
+				"previous_stage":   last.stage(),
+				"elapsed_ms":       time.Since(start).Milliseconds(),
+			})
+		}
+		last = transaction
+
+		switch {
+		case containsString(opts.SuccessStatuses, status):
+			return nil
+		case containsString(opts.FailureStatuses, status):
+			return &transactionError{Transaction: transaction}
+		case containsString(opts.PendingStatuses, status):
+			// Still running, keep waiting.
+		default:
+			if config.FailOnUnknownTransactionStatus {
+				return fmt.Errorf("Transaction returned unrecognized status %q", status)
+			}
+			logAPIEvent(ctx, config, "WARN", "Transaction returned unrecognized status, continuing to poll", map[string]interface{}{
+				"url":                pollingURL,
+				"transaction_status": status,
+			})
+		}
+
+		delay := interval
+		if backoff {
+			delay = pollJitter(interval)
+			interval = nextPollInterval(interval, opts.MaxInterval)
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+
+	return &transactionError{Transaction: last, TimedOut: true, Attempts: maxAttempts}
+}
+
+// transactionError reports a transaction that ended in a failure status, or
+// that was still running when waitFor gave up on it. In the latter case
+// Transaction is the last polling response, if any.
+type transactionError struct {
+	Transaction TransactionResponse
+	TimedOut    bool
+	Attempts    int
+}
+
+func (e *transactionError) Error() string {
+	if e.TimedOut {
+		if stage := e.Transaction.stage(); stage != "" {
+			return fmt.Sprintf("Transaction polling timed out after %d attempts, the last stage reported was %s", e.Attempts, stage)
+		}
+		return fmt.Sprintf("Transaction polling timed out after %d attempts", e.Attempts)
+	}
+	if detail := e.Detail(); detail != "" {
+		return fmt.Sprintf("Transaction failed with status %s: %s", e.Transaction.CDOTransactionStatus, detail)
+	}
+	return fmt.Sprintf("Transaction failed with status %s", e.Transaction.CDOTransactionStatus)
+}
+
+// Detail is what CDO reported about the failure: the error message followed
+// by the error details, sorted by key.
+func (e *transactionError) Detail() string {
+	parts := make([]string, 0, len(e.Transaction.ErrorDetails)+1)
+	if e.Transaction.ErrorMessage != "" {
+		parts = append(parts, e.Transaction.ErrorMessage)
+	}
+
+	keys := make([]string, 0, len(e.Transaction.ErrorDetails))
+	for k := range e.Transaction.ErrorDetails {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %v", k, e.Transaction.ErrorDetails[k]))
+	}
+
+	detail := strings.Join(parts, "; ")
+	if detail != "" && e.Transaction.LastActiveTime != "" {
+		detail = fmt.Sprintf("%s (last active %s)", detail, e.Transaction.LastActiveTime)
+	}
+	return detail
+}
+
+// lastTransactionError returns the failure CDO reported for the transaction
+// behind err, or an empty string when err isn't a failed transaction.
+func lastTransactionError(err error) string {
+	var txErr *transactionError
+	if errors.As(err, &txErr) && !txErr.TimedOut {
+		if detail := txErr.Detail(); detail != "" {
+			return detail
+		}
+		return fmt.Sprintf("Transaction failed with status %s", txErr.Transaction.CDOTransactionStatus)
+	}
+	return ""
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
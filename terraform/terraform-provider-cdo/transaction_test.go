@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testDeviceType = DeviceType{
+	Name:         "test device",
+	DeletePath:   "/devices/%s",
+	DeleteMethod: "DELETE",
+}
+
+func TestDeleteDeviceTransaction(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []string
+		wantState string
+	}{
+		{
+			name:      "done",
+			statuses:  []string{"PENDING", "IN_PROGRESS", "DONE"},
+			wantState: transactionStateDone,
+		},
+		{
+			name:      "error",
+			statuses:  []string{"PENDING", "ERROR"},
+			wantState: transactionStateError,
+		},
+		{
+			name:      "cancelled",
+			statuses:  []string{"CANCELLING", "CANCELLED"},
+			wantState: transactionStateError,
+		},
+		{
+			name:      "timeout",
+			statuses:  []string{"PENDING", "IN_PROGRESS", "IN_PROGRESS", "IN_PROGRESS", "IN_PROGRESS"},
+			wantState: transactionStateTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var polls int32
+			mux := http.NewServeMux()
+			config := newTestConfig(t, mux)
+			mux.HandleFunc("/api/rest/v1/devices/device-1", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "DELETE" {
+					t.Errorf("got %s request, want DELETE", r.Method)
+				}
+				w.WriteHeader(http.StatusAccepted)
+				w.Write([]byte(`{"transactionUid":"tx-1","cdoTransactionStatus":"PENDING","transactionPollingUrl":"` + config.apiURL("/transactions/tx-1") + `"}`))
+			})
+			mux.HandleFunc("/api/rest/v1/transactions/tx-1", func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[atomic.AddInt32(&polls, 1)-1]
+				body := `{"transactionUid":"tx-1","cdoTransactionStatus":"` + status + `"`
+				if status == "ERROR" {
+					body += `,"errorMessage":"device is locked"`
+				}
+				w.Write([]byte(body + "}"))
+			})
+
+			opts := cdoTransactionWait
+			opts.Interval = time.Millisecond
+			opts.MaxAttempts = 4
+			err := deleteDevice(context.Background(), config, testDeviceType, "device-1", opts)
+
+			if got := transactionState(err); got != tt.wantState {
+				t.Fatalf("transactionState() = %s, want %s (error %v)", got, tt.wantState, err)
+			}
+			if tt.wantState == transactionStateError && tt.statuses[len(tt.statuses)-1] == "ERROR" && !strings.Contains(err.Error(), "device is locked") {
+				t.Errorf("error = %q, want it to include the transaction's error message", err)
+			}
+		})
+	}
+}
+
+func TestWaitForUnknownStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    bool
+		wantErr bool
+	}{
+		{name: "keeps polling", fail: false},
+		{name: "fails", fail: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses := []string{"QUEUED", "DONE"}
+			var polls int32
+			config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"cdoTransactionStatus":"` + statuses[atomic.AddInt32(&polls, 1)-1] + `"}`))
+			}))
+			config.FailOnUnknownTransactionStatus = tt.fail
+
+			opts := cdoTransactionWait
+			opts.Interval = time.Millisecond
+			err := waitFor(context.Background(), config, config.apiURL("/transactions/tx-1"), opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("waitFor() error = %v, want error %t", err, tt.wantErr)
+			}
+			var txErr *transactionError
+			if errors.As(err, &txErr) {
+				t.Errorf("waitFor() error = %v, an unknown status isn't a transaction failure", err)
+			}
+		})
+	}
+}
+
+func TestWaitForReportsLastStageOnTimeout(t *testing.T) {
+	stages := []string{"CLAIMED", "PROVISIONING", "REGISTERING"}
+	var polls int32
+	config := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cdoTransactionStatus":"IN_PROGRESS","stage":"` + stages[atomic.AddInt32(&polls, 1)-1] + `"}`))
+	}))
+
+	opts := cdoTransactionWait
+	opts.Interval = time.Millisecond
+	opts.MaxAttempts = len(stages)
+	err := waitFor(context.Background(), config, config.apiURL("/transactions/tx-1"), opts)
+	if transactionState(err) != transactionStateTimeout {
+		t.Fatalf("waitFor() error = %v, want a timeout", err)
+	}
+	if !strings.Contains(err.Error(), "REGISTERING") {
+		t.Errorf("waitFor() error = %q, want it to name the last stage", err)
+	}
+}
+
+func TestPollAttempts(t *testing.T) {
+	tests := []struct {
+		name                       string
+		interval, maxInterval, ttl time.Duration
+		want                       int
+	}{
+		{name: "fixed interval", interval: 10 * time.Second, ttl: time.Minute, want: 6},
+		{name: "backoff", interval: 10 * time.Second, maxInterval: 40 * time.Second, ttl: 150 * time.Second, want: 5},
+		{name: "timeout shorter than interval", interval: time.Minute, ttl: time.Second, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pollAttempts(tt.interval, tt.maxInterval, tt.ttl); got != tt.want {
+				t.Errorf("pollAttempts() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// validateDeviceName accepts the names CDO allows for devices: up to 128
+// letters, digits, spaces, dots, dashes and underscores, starting with a
+// letter or digit.
+var validateDeviceName = validation.All(
+	validation.StringLenBetween(1, 128),
+	validation.StringMatch(regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 ._-]*$`), "must start with a letter or digit and contain only letters, digits, spaces, dots, dashes and underscores"),
+)
+
+// validateSerialNumber accepts FTD serial numbers, which are 11 letters and
+// digits such as JAD2345678X.
+var validateSerialNumber = validation.StringMatch(regexp.MustCompile(`^[A-Za-z0-9]{11}$`), "must be an 11 character alphanumeric FTD serial number")
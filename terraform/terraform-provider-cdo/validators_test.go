@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name      string
+		validate  schema.SchemaValidateFunc
+		value     string
+		wantValid bool
+	}{
+		{name: "name", validate: validateDeviceName, value: "ftd-1", wantValid: true},
+		{name: "name with spaces, dots and underscores", validate: validateDeviceName, value: "Branch 12.edge_fw", wantValid: true},
+		{name: "name of 128 characters", validate: validateDeviceName, value: strings.Repeat("a", 128), wantValid: true},
+		{name: "empty name", validate: validateDeviceName, value: ""},
+		{name: "name of 129 characters", validate: validateDeviceName, value: strings.Repeat("a", 129)},
+		{name: "name starting with a dash", validate: validateDeviceName, value: "-ftd"},
+		{name: "name with a slash", validate: validateDeviceName, value: "ftd/1"},
+		{name: "name with non-ASCII letters", validate: validateDeviceName, value: "pare-feu-é"},
+
+		{name: "serial", validate: validateSerialNumber, value: "JAD2345678X", wantValid: true},
+		{name: "lowercase serial", validate: validateSerialNumber, value: "jad2345678x", wantValid: true},
+		{name: "short serial", validate: validateSerialNumber, value: "JAD234567"},
+		{name: "long serial", validate: validateSerialNumber, value: "JAD2345678XY"},
+		{name: "serial with a dash", validate: validateSerialNumber, value: "JAD-2345678"},
+		{name: "empty serial", validate: validateSerialNumber, value: ""},
+
+		{name: "UUID", validate: validation.IsUUID, value: "7a1b2c3d-0000-4000-8000-000000000001", wantValid: true},
+		{name: "uppercase UUID", validate: validation.IsUUID, value: "7A1B2C3D-0000-4000-8000-000000000001", wantValid: true},
+		{name: "UUID without dashes", validate: validation.IsUUID, value: "7a1b2c3d000040008000000000000001"},
+		{name: "truncated UUID", validate: validation.IsUUID, value: "7a1b2c3d-0000-4000-8000"},
+		{name: "policy name instead of UUID", validate: validation.IsUUID, value: "Default Access Policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := tt.validate(tt.value, "attr")
+			if valid := len(errs) == 0; valid != tt.wantValid {
+				t.Errorf("validate(%q) errors = %v, want valid %t", tt.value, errs, tt.wantValid)
+			}
+		})
+	}
+}
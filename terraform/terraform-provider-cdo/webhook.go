@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// OnboardingSummary is the JSON document posted to the provider's
+// on_success_webhook or on_failure_webhook after a device create.
+type OnboardingSummary struct {
+	Resource     string `json:"resource"`
+	Name         string `json:"name"`
+	SerialNumber string `json:"serial_number,omitempty"`
+	DeviceUID    string `json:"device_uid,omitempty"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+}
+
+// notifyOnboardingResult posts a summary of a finished create to the matching
+// webhook, if one is configured. Webhooks are best effort: failures are
+// logged and never fail the create itself.
+func notifyOnboardingResult(ctx context.Context, config *ProviderConfig, summary OnboardingSummary, createErr error) {
+	webhookURL := config.OnSuccessWebhook
+	summary.Status = "success"
+	if createErr != nil {
+		webhookURL = config.OnFailureWebhook
+		summary.Status = "failure"
+		summary.Error = createErr.Error()
+	}
+	if webhookURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return
+	}
+
+	// The webhook is an external system, so it deliberately doesn't go
+	// through makeRequest and never receives the CDO token. It still has to
+	// go through the proxy, but the CA and pin only identify CDO.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	setProxy(transport, config)
+	client := &http.Client{Transport: transport, Timeout: webhookTimeout}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logAPIEvent(ctx, config, "WARN", "Webhook notification failed", map[string]interface{}{
+			"url":   webhookURL,
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		logAPIEvent(ctx, config, "WARN", "Webhook notification was rejected", map[string]interface{}{
+			"url":    webhookURL,
+			"status": resp.StatusCode,
+		})
+	}
+}
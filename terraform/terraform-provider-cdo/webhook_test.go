@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDeviceCreateNotifiesFailureWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		resource   *schema.Resource
+		deviceType DeviceType
+		wantType   string
+	}{
+		{name: "ASA", resource: resourceASADevice(), deviceType: asaDeviceType, wantType: "cdo_asa_device"},
+		{name: "IOS", resource: resourceIOSDevice(), deviceType: iosDeviceType, wantType: "cdo_ios_device"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summaries := make(chan OnboardingSummary, 1)
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var summary OnboardingSummary
+				json.NewDecoder(r.Body).Decode(&summary)
+				summaries <- summary
+			}))
+			defer webhook.Close()
+
+			mock, config := newMockCDO(t)
+			config.OnFailureWebhook = webhook.URL
+			mock.Handle("POST", tt.deviceType.OnboardPath, func(*mockCDO, map[string]interface{}) (int, interface{}) {
+				return http.StatusBadRequest, map[string]string{"errorCode": "INVALID_INPUT"}
+			})
+
+			d := tt.resource.TestResourceData()
+			d.Set("name", "device-1")
+			d.Set("host", "192.0.2.1")
+			d.Set("sdc_name", "sdc-1")
+			if diags := tt.resource.CreateContext(context.Background(), d, config); !diags.HasError() {
+				t.Fatal("Create() succeeded, want an error")
+			}
+
+			select {
+			case summary := <-summaries:
+				if summary.Resource != tt.wantType || summary.Name != "device-1" || summary.Status != "failure" || summary.Error == "" {
+					t.Errorf("summary = %+v, want a failure of %s device-1", summary, tt.wantType)
+				}
+			default:
+				t.Fatal("failure webhook wasn't called")
+			}
+		})
+	}
+}